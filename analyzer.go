@@ -1,23 +1,191 @@
 package prodinspect
 
 import (
+	"flag"
+	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
 
 	"golang.org/x/tools/go/analysis"
-	"golang.org/x/tools/go/analysis/passes/inspect"
-	"golang.org/x/tools/go/ast/inspector"
 )
 
-var Analyzer = &analysis.Analyzer{
-	Name:             "prodinspect",
-	Doc:              `AST traversal that ignores test and/or generated files`,
-	Requires:         []*analysis.Analyzer{inspect.Analyzer},
-	Run:              run,
-	RunDespiteErrors: true,
-	ResultType:       reflect.TypeOf(new(Inspector)),
+var Analyzer = NewAnalyzer()
+
+// NewAnalyzer returns an analyzer which results in *Inspector configured with opts.
+// The analyzer has flags to configure Rules which is used unless opts has WithClassifier.
+// Drivers don't expose the flags of required analyzers, so main packages need RegisterFlags to make them available.
+// ConfigFile at the module root further configures Rules for all or specific analyzers.
+func NewAnalyzer(opts ...Option) *analysis.Analyzer {
+	var (
+		r          Rules
+		begin, end string
+	)
+	a := analysis.Analyzer{
+		Name: "prodinspect",
+		Doc:  `AST traversal that ignores test and/or generated files`,
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			co, err := OptionsFor(pass.Fset, pass.Files, &r)
+			if err != nil {
+				return nil, err
+			}
+
+			base := append([]Option{WithRegionMarkers(begin, end)}, co...)
+			if pass.Pkg != nil {
+				base = append(base, WithPackagePath(pass.Pkg.Path()))
+			}
+			return NewFromFiles(pass.Fset, pass.Files, append(base, opts...)...), nil
+		},
+		RunDespiteErrors: true,
+		ResultType:       reflect.TypeOf(new(Inspector)),
+	}
+	r.RegisterFlags(&a.Flags)
+	track(&a.Flags, r.explicit, func(fs *flag.FlagSet) {
+		fs.StringVar(&begin, "region-begin", DefaultRegionBegin, "prefix of comments beginning generated regions")
+		fs.StringVar(&end, "region-end", DefaultRegionEnd, "prefix of comments ending generated regions")
+	})
+	return &a
+}
+
+// RegisterFlags defines the flags of a, which is Analyzer or one returned by NewAnalyzer, in fs as -<name>.<flag>, e.g. -prodinspect.include-tests.
+// Drivers such as singlechecker, multichecker and unitchecker only define the flags of the analyzers given to them.
+// A main package whose analyzers require a has to call it with flag.CommandLine before running the driver to expose the flags.
+func RegisterFlags(fs *flag.FlagSet, a *analysis.Analyzer) {
+	a.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, a.Name+"."+f.Name, f.Usage)
+	})
+}
+
+// RegisterFlags defines flags to configure r in fs.
+// Flags set explicitly take precedence over ConfigFile.
+func (r *Rules) RegisterFlags(fs *flag.FlagSet) {
+	if r.explicit == nil {
+		r.explicit = map[string]bool{}
+	}
+	track(fs, r.explicit, r.defineFlags)
+}
+
+func (r *Rules) defineFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&r.IncludeTests, "include-tests", r.IncludeTests, "treat test files as production")
+	fs.BoolVar(&r.IncludeGenerated, "include-generated", r.IncludeGenerated, "treat generated files as production")
+	fs.Var((*globsFlag)(&r.Include), "include", "glob `pattern` of production files regardless of the other rules (repeatable)")
+	fs.Var((*globsFlag)(&r.Exclude), "exclude", "glob `pattern` of non-production files (repeatable)")
+	fs.Var((*tagsFlag)(&r.BuildTags), "build-tags", "comma-separated build `tags` which make files non-production")
+	fs.Var((*regexpsFlag)(&r.GeneratedMarkers), "generated-marker", "`regexp` of comment lines marking generated files (repeatable)")
+	fs.Var((*globsFlag)(&r.TestSupportPackages), "test-support-package", "glob `pattern` of test support package paths (repeatable)")
+	fs.Var((*globsFlag)(&r.TestSupportFiles), "test-support-file", "glob `pattern` of test support files (repeatable)")
+	fs.BoolVar(&r.TestSupportImports, "test-support-imports", r.TestSupportImports, "treat packages importing \"testing\" in non-test files as test support")
+	fs.Var((*generatorsFlag)(&r.Generators), "generators", "comma-separated `names` of known generators to recognize: "+strings.Join(generatorNames(), ","))
+	fs.BoolVar(&r.GitAttributes, "gitattributes", r.GitAttributes, "treat files marked as linguist-generated in .gitattributes as generated")
+	fs.BoolVar(&r.LineDirectives, "line-directives", r.LineDirectives, "classify files by names adjusted by //line directives and treat files mapped from non-Go sources as generated")
+}
+
+// track defines flags by define in fs and records the names of the flags set explicitly in explicit.
+func track(fs *flag.FlagSet, explicit map[string]bool, define func(*flag.FlagSet)) {
+	var d flag.FlagSet
+	define(&d)
+	d.VisitAll(func(f *flag.Flag) {
+		fs.Var(&trackedFlag{Value: f.Value, name: f.Name, explicit: explicit}, f.Name, f.Usage)
+	})
+}
+
+type trackedFlag struct {
+	flag.Value
+	name     string
+	explicit map[string]bool
+}
+
+func (t *trackedFlag) Set(s string) error {
+	t.explicit[t.name] = true
+	return t.Value.Set(s)
+}
+
+func (t *trackedFlag) IsBoolFlag() bool {
+	b, ok := t.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+type globsFlag []string
+
+func (g *globsFlag) String() string {
+	return strings.Join(*g, ",")
 }
 
-func run(pass *analysis.Pass) (interface{}, error) {
-	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
-	return New(inspect, pass.Fset), nil
+func (g *globsFlag) Set(s string) error {
+	*g = append(*g, s)
+	return nil
 }
+
+type regexpsFlag []*regexp.Regexp
+
+func (r *regexpsFlag) String() string {
+	ss := make([]string, len(*r))
+	for i, e := range *r {
+		ss[i] = e.String()
+	}
+	return strings.Join(ss, ",")
+}
+
+func (r *regexpsFlag) Set(s string) error {
+	e, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, e)
+	return nil
+}
+
+type tagsFlag []string
+
+func (t *tagsFlag) String() string {
+	if *t == nil {
+		return strings.Join(DefaultBuildTags, ",")
+	}
+	return strings.Join(*t, ",")
+}
+
+func (t *tagsFlag) Set(s string) error {
+	*t = []string{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
+}
+
+type generatorsFlag []string
+
+func (g *generatorsFlag) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *generatorsFlag) Set(s string) error {
+	for _, n := range strings.Split(s, ",") {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		if _, ok := generator(n); !ok {
+			return fmt.Errorf("unknown generator: %s", n)
+		}
+		*g = append(*g, n)
+	}
+	return nil
+}
+
+func generatorNames() []string {
+	ns := make([]string, len(Generators))
+	for i, g := range Generators {
+		ns[i] = g.Name
+	}
+	return ns
+}
+
+var (
+	_ flag.Value = (*trackedFlag)(nil)
+	_ flag.Value = (*globsFlag)(nil)
+	_ flag.Value = (*regexpsFlag)(nil)
+	_ flag.Value = (*tagsFlag)(nil)
+	_ flag.Value = (*generatorsFlag)(nil)
+)
@@ -1,10 +1,17 @@
 package prodinspect
 
 import (
+	"go/ast"
+	"go/build"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
@@ -16,3 +23,169 @@ func TestFromFileSystem(t *testing.T) {
 
 	assert.IsType((*Inspector)(nil), rs[0].Result)
 }
+
+func TestFromFileSystem_Cgo(t *testing.T) {
+	if !build.Default.CgoEnabled {
+		t.Skip("cgo is not enabled")
+	}
+
+	assert := assert.New(t)
+
+	testdata := analysistest.TestData()
+	rs := analysistest.Run(t, testdata, Analyzer, "cgo")
+
+	src := filepath.Join(testdata, "src", "cgo", "cgo.go")
+	for _, r := range rs {
+		if r.Pass.Pkg.Name() != "cgo" {
+			continue
+		}
+
+		i := r.Result.(*Inspector)
+
+		var glue int
+		for _, f := range r.Pass.Files {
+			c := i.Classify(f)
+			switch name := r.Pass.Fset.File(f.Pos()).Name(); {
+			case c.Reason == "cgo glue":
+				assert.Equal(Generated, c.Kind)
+				glue++
+			case strings.HasSuffix(name, "cgo_test.go"):
+				assert.Equal(Test, c.Kind)
+			default:
+				assert.Equal(Classification{Kind: Production, Reason: "processed by cgo from " + src}, c)
+			}
+		}
+		assert.Equal(2, glue)
+
+		var funcs []string
+		i.Preorder([]ast.Node{
+			(*ast.FuncDecl)(nil),
+		}, func(n ast.Node) {
+			funcs = append(funcs, n.(*ast.FuncDecl).Name.Name)
+		})
+		assert.Equal([]string{"Random"}, funcs)
+	}
+}
+
+func TestFromFileSystem_CgoClassifier(t *testing.T) {
+	if !build.Default.CgoEnabled {
+		t.Skip("cgo is not enabled")
+	}
+
+	assert := assert.New(t)
+
+	testdata := analysistest.TestData()
+	a := NewAnalyzer(WithClassifier(Or(TestFiles, GeneratedFiles)))
+	rs := analysistest.Run(t, testdata, a, "cgo")
+
+	for _, r := range rs {
+		if r.Pass.Pkg.Name() != "cgo" {
+			continue
+		}
+
+		i := r.Result.(*Inspector)
+
+		var glue int
+		for _, f := range r.Pass.Files {
+			c := i.Classify(f)
+			switch name := r.Pass.Fset.File(f.Pos()).Name(); {
+			case c.Reason == "cgo glue":
+				assert.Equal(Generated, c.Kind)
+				glue++
+			case strings.HasSuffix(name, "cgo_test.go"):
+				assert.Equal(Test, c.Kind)
+			default:
+				assert.Equal(Production, c.Kind, c.String())
+			}
+		}
+		assert.Equal(2, glue)
+	}
+}
+
+func TestNewAnalyzer(t *testing.T) {
+	t.Run("flags", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := NewAnalyzer()
+		assert.NoError(a.Flags.Set("include-tests", "true"))
+		assert.NoError(a.Flags.Set("exclude", "*_mock.go"))
+		assert.NoError(a.Flags.Set("exclude", "mocks/*"))
+		assert.NoError(a.Flags.Set("generated-marker", "^// Autogenerated"))
+		assert.Error(a.Flags.Set("generated-marker", "("))
+		assert.NoError(a.Flags.Set("generators", "thrift, kubernetes"))
+		assert.Error(a.Flags.Set("generators", "unknown"))
+		assert.NoError(a.Flags.Set("test-support-file", "fakes/*.go"))
+		assert.NoError(a.Flags.Set("include", "keep_mock.go"))
+		assert.Equal("ignore,tools", a.Flags.Lookup("build-tags").Value.String())
+		assert.NoError(a.Flags.Set("build-tags", "ignore,integration"))
+
+		fs := token.NewFileSet()
+		prod := parse(t, fs, "foo.go", "package foo")
+		test := parse(t, fs, "foo_test.go", "package foo")
+		mock := parse(t, fs, "foo_mock.go", "package foo")
+		gen := parse(t, fs, "bar.go", "// Autogenerated by hand\n\npackage foo")
+		deepcopy := parse(t, fs, "zz_generated.deepcopy.go", "package foo")
+		fake := parse(t, fs, "fakes/foo.go", "package fakes")
+		keep := parse(t, fs, "keep_mock.go", "package foo")
+		integration := parse(t, fs, "integration.go", "//go:build integration\n\npackage foo")
+		tools := parse(t, fs, "tools.go", "//go:build tools\n\npackage foo")
+
+		r, err := a.Run(&analysis.Pass{
+			Fset:  fs,
+			Files: []*ast.File{prod, test, mock, gen, deepcopy, fake, integration, tools, keep},
+		})
+		assert.NoError(err)
+
+		i := r.(*Inspector)
+		assert.Equal(Production, i.Classify(prod).Kind)
+		assert.Equal(Production, i.Classify(test).Kind)
+		assert.Equal(Excluded, i.Classify(mock).Kind)
+		assert.Equal(Generated, i.Classify(gen).Kind)
+		assert.Equal(Generated, i.Classify(deepcopy).Kind)
+		assert.Equal(TestSupport, i.Classify(fake).Kind)
+		assert.Equal(Production, i.Classify(keep).Kind)
+		assert.Equal(Excluded, i.Classify(integration).Kind)
+		assert.Equal(Production, i.Classify(tools).Kind)
+		assert.Equal("thrift,kubernetes", a.Flags.Lookup("generators").Value.String())
+		assert.Equal("*_mock.go,mocks/*", a.Flags.Lookup("exclude").Value.String())
+		assert.Equal("^// Autogenerated", a.Flags.Lookup("generated-marker").Value.String())
+	})
+
+	t.Run("classifier", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := NewAnalyzer(WithClassifier(TestFiles))
+		assert.NoError(a.Flags.Set("include-tests", "true"))
+
+		fs := token.NewFileSet()
+		test := parse(t, fs, "foo_test.go", "package foo")
+
+		r, err := a.Run(&analysis.Pass{
+			Fset:  fs,
+			Files: []*ast.File{test},
+		})
+		assert.NoError(err)
+		assert.Equal(Test, r.(*Inspector).Classify(test).Kind)
+	})
+}
+
+func TestRegisterFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	bin := filepath.Join(t.TempDir(), "checker")
+	if out, err := exec.Command("go", "build", "-o", bin, "./testdata/checker").CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	out, _ := exec.Command(bin, "-help").CombinedOutput()
+	assert.Contains(string(out), "-prodinspect.include-tests")
+	assert.Contains(string(out), "-prodinspect.region-begin")
+
+	out, _ = exec.Command(bin, "./testdata/src/a").CombinedOutput()
+	assert.Contains(string(out), ": Foo")
+	assert.NotContains(string(out), ": TestFoo")
+
+	out, _ = exec.Command(bin, "-prodinspect.include-tests", "./testdata/src/a").CombinedOutput()
+	assert.Contains(string(out), ": Foo")
+	assert.Contains(string(out), ": TestFoo")
+}
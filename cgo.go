@@ -0,0 +1,57 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+const cgoMarker = "// Code generated by cmd/cgo; DO NOT EDIT."
+
+// cgo returns the original file name if f is a Go file processed by cgo.
+// It also reports whether f is glue code generated by cgo such as _cgo_gotypes.go and _cgo_import.go.
+// Since the analysis framework hands files in the build cache with mangled names, they're recognized by their contents.
+func cgo(f *ast.File, tok *token.File) (src string, glue bool) {
+	var marked bool
+	for _, c := range f.Comments {
+		for _, l := range c.List {
+			if l.Pos() > f.Package {
+				// _cgo_import.go has only directives after the package clause.
+				return "", marked || strings.HasPrefix(l.Text, "//go:cgo_import_dynamic ")
+			}
+
+			if l.Text == cgoMarker {
+				marked = true
+				continue
+			}
+
+			// Processed files have //line directive to the original file right after the marker.
+			if name, ok := lineFile(tok, l); marked && ok {
+				return name, false
+			}
+		}
+	}
+	return "", marked
+}
+
+// withoutCgoMarker returns a shallow copy of f without the generated marker by cgo.
+func withoutCgoMarker(f *ast.File) *ast.File {
+	g := *f
+	g.Comments = make([]*ast.CommentGroup, 0, len(f.Comments))
+	for _, c := range f.Comments {
+		var h ast.CommentGroup
+		for _, l := range c.List {
+			if l.Text != cgoMarker {
+				h.List = append(h.List, l)
+			}
+		}
+		switch len(h.List) {
+		case 0:
+		case len(c.List):
+			g.Comments = append(g.Comments, c)
+		default:
+			g.Comments = append(g.Comments, &h)
+		}
+	}
+	return &g
+}
@@ -0,0 +1,104 @@
+package prodinspect
+
+import (
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCgo(t *testing.T) {
+	t.Run("processed", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fset := token.NewFileSet()
+		f := parse(t, fset, "/cache/06/0683-d", `// Code generated by cmd/cgo; DO NOT EDIT.
+
+//line /src/foo/foo.go:1:1
+// Code generated by a generator; DO NOT EDIT.
+
+package foo
+`)
+
+		src, glue := cgo(f, fset.File(f.Pos()))
+		assert.Equal("/src/foo/foo.go", src)
+		assert.False(glue)
+
+		g := withoutCgoMarker(f)
+		assert.Len(f.Comments, 2)
+		assert.Len(g.Comments, 1)
+		assert.Equal(Generated, classifyGenerated(&File{AST: g}, GeneratedHeader).Kind)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fset := token.NewFileSet()
+		f := parse(t, fset, "/cache/06/0683-d", `// Code generated by cmd/cgo; DO NOT EDIT.
+
+//line /src/foo/foo_test.go:1:1
+package foo
+`)
+
+		file := NewFile(f, fset.File(f.Pos()))
+		assert.Equal("/src/foo/foo_test.go", file.Original)
+		assert.Equal("/src/foo/foo_test.go", file.Name(false))
+		assert.False(file.CgoGlue)
+		assert.NotEqual(cgoMarker, file.AST.Comments[0].List[0].Text)
+		assert.Equal(Classification{Kind: Test, Reason: "_test.go suffix"}, TestFiles.Classify(file))
+		assert.Equal(Production, GeneratedFiles.Classify(file).Kind)
+
+		g := parse(t, fset, "/cache/83/838f-d", `package foo
+//go:cgo_import_dynamic random random#GLIBC_2.2.5 "libc.so.6"
+`)
+		glue := NewFile(g, fset.File(g.Pos()))
+		assert.True(glue.CgoGlue)
+		assert.Equal(Classification{Kind: Generated, Reason: "cgo glue"}, GeneratedFiles.Classify(glue))
+	})
+
+	t.Run("gotypes", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fset := token.NewFileSet()
+		f := parse(t, fset, "/cache/d7/d70f-d", `//go:cgo_ldflag "-O2"
+// Code generated by cmd/cgo; DO NOT EDIT.
+
+package foo
+
+import "unsafe"
+`)
+
+		src, glue := cgo(f, fset.File(f.Pos()))
+		assert.Empty(src)
+		assert.True(glue)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fset := token.NewFileSet()
+		f := parse(t, fset, "/cache/83/838f-d", `package foo
+//go:cgo_import_dynamic random random#GLIBC_2.2.5 "libc.so.6"
+`)
+
+		src, glue := cgo(f, fset.File(f.Pos()))
+		assert.Empty(src)
+		assert.True(glue)
+	})
+
+	t.Run("hand-written", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fset := token.NewFileSet()
+		f := parse(t, fset, "/src/foo/foo.go", `// Package foo does nothing.
+package foo
+
+// Foo does nothing.
+func Foo() {}
+`)
+
+		src, glue := cgo(f, fset.File(f.Pos()))
+		assert.Empty(src)
+		assert.False(glue)
+	})
+}
@@ -0,0 +1,434 @@
+package prodinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileKind is a kind of a Go file.
+type FileKind int
+
+const (
+	Production FileKind = iota
+	Test
+	ExternalTest
+	Generated
+	GeneratedTest
+	Fixture
+	Excluded
+	TestSupport
+)
+
+var fileKindNames = [...]string{
+	Production:    "production",
+	Test:          "test",
+	ExternalTest:  "external test",
+	Generated:     "generated",
+	GeneratedTest: "generated test",
+	Fixture:       "fixture",
+	Excluded:      "excluded",
+	TestSupport:   "test support",
+}
+
+func (k FileKind) String() string {
+	if k < 0 || int(k) >= len(fileKindNames) {
+		return fmt.Sprintf("FileKind(%d)", k)
+	}
+	return fileKindNames[k]
+}
+
+func (k FileKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// IsProduction reports whether k is Production.
+func (k FileKind) IsProduction() bool {
+	return k == Production
+}
+
+// Classification is a verdict of a Classifier with a human-readable reason.
+type Classification struct {
+	Kind   FileKind
+	Reason string
+}
+
+func (c Classification) String() string {
+	if c.Reason == "" {
+		return c.Kind.String()
+	}
+	return fmt.Sprintf("%s (%s)", c.Kind, c.Reason)
+}
+
+// File is a Go file to be classified.
+type File struct {
+	// AST is the syntax tree of the file. For files processed by cgo, the generated marker by cgo is stripped.
+	AST   *ast.File
+	Token *token.File
+
+	// Original is the name of the original file if the file was processed by cgo.
+	Original string
+
+	// CgoGlue reports whether the file is glue code generated by cgo such as _cgo_gotypes.go.
+	CgoGlue bool
+
+	// PkgPath is the import path of the package of the file if known.
+	PkgPath string
+
+	// PkgFiles are the files of the package including the file itself if known.
+	PkgFiles []*File
+}
+
+// NewFile returns a File for f which is mapped back to the original file if it was processed by cgo.
+func NewFile(f *ast.File, tok *token.File) *File {
+	src, glue := cgo(f, tok)
+	if src != "" {
+		f = withoutCgoMarker(f)
+	}
+	return &File{
+		AST:      f,
+		Token:    tok,
+		Original: src,
+		CgoGlue:  glue,
+	}
+}
+
+// Name returns the name of the file. For files processed by cgo, it's the name of the original file.
+// If adjusted is true, the name is adjusted by //line directive for the package clause.
+func (f *File) Name(adjusted bool) string {
+	if f.Original != "" {
+		return f.Original
+	}
+	if adjusted && f.AST.Package.IsValid() {
+		return f.Token.PositionFor(f.AST.Package, true).Filename
+	}
+	return f.Token.Name()
+}
+
+// Classifier decides the kind of a file.
+type Classifier interface {
+	Classify(f *File) Classification
+}
+
+// ClassifierFunc is an adapter to use an ordinary function as a Classifier.
+type ClassifierFunc func(f *File) Classification
+
+func (c ClassifierFunc) Classify(f *File) Classification {
+	return c(f)
+}
+
+var (
+	// TestFiles classifies files with _test.go suffix as Test or ExternalTest.
+	TestFiles Classifier = ClassifierFunc(func(f *File) Classification {
+		return classifyTest(f, f.Name(false))
+	})
+
+	// GeneratedFiles classifies files with `// Code generated * DO NOT EDIT.` comment before the package clause as Generated.
+	GeneratedFiles Classifier = ClassifierFunc(func(f *File) Classification {
+		return classifyGenerated(f, GeneratedHeader)
+	})
+
+	// Fixtures classifies files in testdata directories as Fixture.
+	Fixtures Classifier = ClassifierFunc(func(f *File) Classification {
+		for _, e := range strings.Split(filepath.ToSlash(filepath.Dir(f.Name(false))), "/") {
+			if e == "testdata" {
+				return Classification{Kind: Fixture, Reason: "testdata directory"}
+			}
+		}
+		return Classification{Kind: Production}
+	})
+
+	// DefaultClassifier classifies test files and generated files.
+	DefaultClassifier Classifier = &Rules{}
+)
+
+// Rules is a Classifier configurable by its fields.
+// The zero value classifies test files and generated files.
+type Rules struct {
+	// IncludeTests makes test files production.
+	IncludeTests bool
+
+	// IncludeGenerated makes generated files production.
+	IncludeGenerated bool
+
+	// Include is a list of glob patterns of files classified as Production regardless of the other rules
+	// in the same manner as Exclude.
+	Include []string
+
+	// Exclude is a list of glob patterns of files classified as Excluded.
+	// A pattern without a slash matches the base name. Otherwise, it matches the trailing elements of the path.
+	Exclude []string
+
+	// BuildTags are build tags which make files non-production.
+	// Files whose build constraints can't be satisfied without one of them are classified as Excluded.
+	// If it's nil, DefaultBuildTags is used.
+	BuildTags []string
+
+	// GeneratedMarkers are regular expressions of comment lines considered as the generated marker
+	// in addition to `// Code generated * DO NOT EDIT.`.
+	GeneratedMarkers []*regexp.Regexp
+
+	// GeneratedMode is how to find the generated marker.
+	GeneratedMode GeneratedMode
+
+	// Generators are names of generators in the catalog Generators to recognize.
+	Generators []string
+
+	// TestSupportPackages is a list of glob patterns of package paths classified as TestSupport such as `internal/testutil`.
+	// A pattern matches the trailing elements of the path.
+	TestSupportPackages []string
+
+	// TestSupportFiles is a list of glob patterns of files classified as TestSupport such as `*_mock.go` in the same manner as Exclude.
+	TestSupportFiles []string
+
+	// TestSupportImports classifies files of packages which import "testing" in non-test files as TestSupport.
+	TestSupportImports bool
+
+	// GitAttributes classifies files marked as linguist-generated in .gitattributes files as Generated.
+	// .gitattributes files are looked up from the directory of the file up to the root of the repository.
+	GitAttributes bool
+
+	// LineDirectives makes file names adjusted by //line directives and files mapped from non-Go sources Generated.
+	LineDirectives bool
+
+	// explicit is the names of the flags set explicitly, which ConfigFile doesn't override.
+	explicit map[string]bool
+}
+
+func (r *Rules) Classify(f *File) Classification {
+	name := f.Name(r.LineDirectives)
+
+	if p, ok := match(name, r.Include); ok {
+		return Classification{Kind: Production, Reason: fmt.Sprintf("included by %q", p)}
+	}
+
+	if p, ok := match(name, r.Exclude); ok {
+		return Classification{Kind: Excluded, Reason: fmt.Sprintf("matches %q", p)}
+	}
+
+	if c := r.classifyConstraint(f); !c.Kind.IsProduction() {
+		return c
+	}
+
+	t := classifyTest(f, name)
+	if t.Kind.IsProduction() {
+		if c := r.classifyTestSupport(f, name); !c.Kind.IsProduction() {
+			return c
+		}
+	}
+	if r.IncludeTests {
+		t = Classification{Kind: Production}
+	}
+
+	var g Classification
+	if !r.IncludeGenerated {
+		g = classifyGenerated(f, r.GeneratedMode, r.GeneratedMarkers...)
+		if g.Kind.IsProduction() {
+			g = classifyGenerators(f, name, r.GeneratedMode, r.Generators)
+		}
+		if r.GitAttributes && g.Kind.IsProduction() {
+			if by, ok := linguistGeneratedBy(name); ok {
+				g = Classification{Kind: Generated, Reason: fmt.Sprintf("linguist-generated in %s", by)}
+			}
+		}
+		if r.LineDirectives && g.Kind.IsProduction() {
+			if o, ok := origin(f); ok {
+				g = Classification{Kind: Generated, Reason: fmt.Sprintf("//line directive to %s", o)}
+			}
+		}
+	}
+	switch {
+	case !t.Kind.IsProduction() && !g.Kind.IsProduction():
+		return Classification{Kind: GeneratedTest, Reason: t.Reason + ", " + g.Reason}
+	case !t.Kind.IsProduction():
+		return t
+	case !g.Kind.IsProduction():
+		return g
+	case f.Original != "":
+		return Classification{Kind: Production, Reason: fmt.Sprintf("processed by cgo from %s", f.Original)}
+	default:
+		return g
+	}
+}
+
+func classifyTest(f *File, name string) Classification {
+	if !strings.HasSuffix(name, "_test.go") {
+		return Classification{Kind: Production}
+	}
+	if f.AST.Name != nil && strings.HasSuffix(f.AST.Name.Name, "_test") {
+		return Classification{Kind: ExternalTest, Reason: "_test.go suffix and _test package"}
+	}
+	return Classification{Kind: Test, Reason: "_test.go suffix"}
+}
+
+func (r *Rules) classifyConstraint(f *File) Classification {
+	tags := r.BuildTags
+	if tags == nil {
+		tags = DefaultBuildTags
+	}
+	if len(tags) == 0 {
+		return Classification{Kind: Production}
+	}
+	x, line, ok := buildConstraint(f.AST)
+	if !ok {
+		return Classification{Kind: Production}
+	}
+	if req, ok := requires(x, tags); ok {
+		return Classification{Kind: Excluded, Reason: fmt.Sprintf("%q requires %s", line, strings.Join(req, ","))}
+	}
+	return Classification{Kind: Production}
+}
+
+func (r *Rules) classifyTestSupport(f *File, name string) Classification {
+	if p, ok := match(f.PkgPath, r.TestSupportPackages); ok && f.PkgPath != "" {
+		return Classification{Kind: TestSupport, Reason: fmt.Sprintf("package matches %q", p)}
+	}
+	if p, ok := match(name, r.TestSupportFiles); ok {
+		return Classification{Kind: TestSupport, Reason: fmt.Sprintf("matches %q", p)}
+	}
+	if r.TestSupportImports {
+		fs := f.PkgFiles
+		if len(fs) == 0 {
+			fs = []*File{f}
+		}
+		for _, pf := range fs {
+			if strings.HasSuffix(pf.Name(r.LineDirectives), "_test.go") {
+				continue
+			}
+			for _, s := range pf.AST.Imports {
+				if p, _ := strconv.Unquote(s.Path.Value); p == "testing" {
+					return Classification{Kind: TestSupport, Reason: `package imports "testing" in non-test files`}
+				}
+			}
+		}
+	}
+	return Classification{Kind: Production}
+}
+
+// origin returns the first non-Go source file which //line directives in f map to.
+func origin(f *File) (string, bool) {
+	for _, c := range f.AST.Comments {
+		for _, l := range c.List {
+			if name, ok := lineFile(f.Token, l); ok && filepath.Ext(name) != ".go" {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// lineFile returns the file name of a //line directive.
+// As go/scanner does, the directive has to end with :line or :line:col, and //line has to start at column 1.
+func lineFile(tok *token.File, c *ast.Comment) (string, bool) {
+	var name string
+	switch {
+	case strings.HasPrefix(c.Text, "//line "):
+		if tok != nil && tok.PositionFor(c.Pos(), false).Column != 1 {
+			return "", false
+		}
+		name = strings.TrimPrefix(c.Text, "//line ")
+	case strings.HasPrefix(c.Text, "/*line ") && strings.HasSuffix(c.Text, "*/"):
+		name = strings.TrimSuffix(strings.TrimPrefix(c.Text, "/*line "), "*/")
+	default:
+		return "", false
+	}
+
+	// Trim :line and :line:col.
+	name, ok := trimNumber(name)
+	if !ok {
+		return "", false
+	}
+	if n, ok := trimNumber(name); ok {
+		name = n
+	}
+
+	return name, name != ""
+}
+
+// trimNumber trims a trailing colon followed by a positive number.
+func trimNumber(s string) (string, bool) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return s, false
+	}
+	if n, err := strconv.Atoi(s[i+1:]); err != nil || n <= 0 {
+		return s, false
+	}
+	return s[:i], true
+}
+
+func classifyGenerated(f *File, mode GeneratedMode, markers ...*regexp.Regexp) Classification {
+	if f.CgoGlue {
+		return Classification{Kind: Generated, Reason: "cgo glue"}
+	}
+	if l, ok := generated(f.AST, mode, markers...); ok {
+		return Classification{Kind: Generated, Reason: fmt.Sprintf("%q comment", l)}
+	}
+	return Classification{Kind: Production}
+}
+
+// match returns the first glob pattern which matches name.
+func match(name string, patterns []string) (string, bool) {
+	elems := strings.Split(filepath.ToSlash(name), "/")
+	for _, p := range patterns {
+		n := strings.Count(p, "/") + 1
+		if n > len(elems) {
+			continue
+		}
+		if ok, _ := path.Match(p, strings.Join(elems[len(elems)-n:], "/")); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// And classifies a file as non-production if all of the classifiers do so.
+// The verdict of the first classifier is used in that case.
+func And(cs ...Classifier) Classifier {
+	return ClassifierFunc(func(f *File) Classification {
+		var first Classification
+		for i, c := range cs {
+			r := c.Classify(f)
+			if r.Kind.IsProduction() {
+				return r
+			}
+			if i == 0 {
+				first = r
+			}
+		}
+		return first
+	})
+}
+
+// Or classifies a file as non-production if any of the classifiers does so.
+// The verdict of the first classifier which classifies the file as non-production is used in that case.
+func Or(cs ...Classifier) Classifier {
+	return ClassifierFunc(func(f *File) Classification {
+		for _, c := range cs {
+			if r := c.Classify(f); !r.Kind.IsProduction() {
+				return r
+			}
+		}
+		return Classification{Kind: Production}
+	})
+}
+
+// Not classifies a file as Excluded if the classifier classifies it as production and vice versa.
+func Not(c Classifier) Classifier {
+	return ClassifierFunc(func(f *File) Classification {
+		r := c.Classify(f)
+		reason := "negated"
+		if r.Reason != "" {
+			reason = fmt.Sprintf("negated %s", r.Reason)
+		}
+		if r.Kind.IsProduction() {
+			return Classification{Kind: Excluded, Reason: reason}
+		}
+		return Classification{Kind: Production, Reason: reason}
+	})
+}
@@ -0,0 +1,326 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileKind_String(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("production", Production.String())
+	assert.Equal("external test", ExternalTest.String())
+	assert.Equal("generated test", GeneratedTest.String())
+	assert.Equal("test support", TestSupport.String())
+	assert.Equal("FileKind(100)", FileKind(100).String())
+}
+
+func TestTestFiles(t *testing.T) {
+	t.Run("test file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "foo_test.go", "package foo")
+
+		assert.Equal(Classification{Kind: Test, Reason: "_test.go suffix"}, TestFiles.Classify(f))
+	})
+
+	t.Run("external test file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "foo_test.go", "package foo_test")
+
+		assert.Equal(Classification{Kind: ExternalTest, Reason: "_test.go suffix and _test package"}, TestFiles.Classify(f))
+	})
+
+	t.Run("non-test file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "foo.go", "package foo")
+
+		assert.Equal(Classification{Kind: Production}, TestFiles.Classify(f))
+	})
+}
+
+func TestGeneratedFiles(t *testing.T) {
+	t.Run("generated file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "foo.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")
+
+		assert.Equal(Classification{Kind: Generated, Reason: `"// Code generated by a generator; DO NOT EDIT." comment`}, GeneratedFiles.Classify(f))
+	})
+
+	t.Run("non-generated file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "foo.go", "// Package foo does nothing.\npackage foo")
+
+		assert.Equal(Classification{Kind: Production}, GeneratedFiles.Classify(f))
+	})
+}
+
+func TestFixtures(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(Fixture, Fixtures.Classify(file(t, "testdata/src/a/a.go", "package a")).Kind)
+	assert.Equal(Production, Fixtures.Classify(file(t, "src/testdatabase/a.go", "package a")).Kind)
+}
+
+func TestDefaultClassifier(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(Production, DefaultClassifier.Classify(file(t, "foo.go", "package foo")).Kind)
+	assert.Equal(Test, DefaultClassifier.Classify(file(t, "foo_test.go", "package foo")).Kind)
+	assert.Equal(ExternalTest, DefaultClassifier.Classify(file(t, "foo_test.go", "package foo_test")).Kind)
+	assert.Equal(Generated, DefaultClassifier.Classify(file(t, "foo.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")).Kind)
+	assert.Equal(Classification{
+		Kind:   GeneratedTest,
+		Reason: `_test.go suffix, "// Code generated by a generator; DO NOT EDIT." comment`,
+	}, DefaultClassifier.Classify(file(t, "foo_test.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")))
+}
+
+func TestRules_Classify(t *testing.T) {
+	t.Run("generated header", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{}
+
+		assert.Equal(Generated, r.Classify(file(t, "foo.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")).Kind)
+		assert.Equal(Production, r.Classify(file(t, "foo.go", "package foo\n\n// Code generated by a generator; DO NOT EDIT.")).Kind)
+	})
+
+	t.Run("generated anywhere", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{GeneratedMode: GeneratedAnywhere}
+
+		assert.Equal(Generated, r.Classify(file(t, "foo.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")).Kind)
+		assert.Equal(Generated, r.Classify(file(t, "foo.go", "package foo\n\n// Code generated by a generator; DO NOT EDIT.")).Kind)
+	})
+}
+
+func TestRules_Classify_Flags(t *testing.T) {
+	t.Run("include tests", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{IncludeTests: true}
+
+		assert.Equal(Production, r.Classify(file(t, "foo_test.go", "package foo")).Kind)
+		assert.Equal(Generated, r.Classify(file(t, "foo_test.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")).Kind)
+	})
+
+	t.Run("include generated", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{IncludeGenerated: true}
+
+		assert.Equal(Production, r.Classify(file(t, "foo.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")).Kind)
+		assert.Equal(Test, r.Classify(file(t, "foo_test.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")).Kind)
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{Exclude: []string{"*_mock.go", "mocks/*.go"}}
+
+		assert.Equal(Classification{Kind: Excluded, Reason: `matches "*_mock.go"`}, r.Classify(file(t, "/src/foo/foo_mock.go", "package foo")))
+		assert.Equal(Classification{Kind: Excluded, Reason: `matches "mocks/*.go"`}, r.Classify(file(t, "/src/foo/mocks/foo.go", "package mocks")))
+		assert.Equal(Production, r.Classify(file(t, "/src/foo/foo.go", "package foo")).Kind)
+		assert.Equal(Production, r.Classify(file(t, "/src/mocks/foo/foo.go", "package foo")).Kind)
+	})
+
+	t.Run("include", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{Include: []string{"gen/keep*.go"}, Exclude: []string{"gen/*.go"}}
+
+		assert.Equal(Classification{Kind: Production, Reason: `included by "gen/keep*.go"`}, r.Classify(file(t, "/src/foo/gen/keep_test.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage gen")))
+		assert.Equal(Excluded, r.Classify(file(t, "/src/foo/gen/foo.go", "package gen")).Kind)
+	})
+
+	t.Run("generated markers", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{GeneratedMarkers: []*regexp.Regexp{regexp.MustCompile(`^// Autogenerated by Thrift`)}}
+
+		assert.Equal(Classification{Kind: Generated, Reason: `"// Autogenerated by Thrift Compiler" comment`}, r.Classify(file(t, "foo.go", "// Autogenerated by Thrift Compiler\n\npackage foo")))
+		assert.Equal(Generated, r.Classify(file(t, "foo.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo")).Kind)
+		assert.Equal(Production, r.Classify(file(t, "foo.go", "package foo")).Kind)
+	})
+}
+
+func TestRules_Classify_TestSupport(t *testing.T) {
+	t.Run("packages", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{TestSupportPackages: []string{"internal/testutil", "*testing"}}
+
+		f := file(t, "util.go", "package testutil")
+		f.PkgPath = "example.com/foo/internal/testutil"
+		assert.Equal(Classification{Kind: TestSupport, Reason: `package matches "internal/testutil"`}, r.Classify(f))
+
+		f = file(t, "util.go", "package iotesting")
+		f.PkgPath = "example.com/foo/iotesting"
+		assert.Equal(Classification{Kind: TestSupport, Reason: `package matches "*testing"`}, r.Classify(f))
+
+		f = file(t, "util_test.go", "package testutil")
+		f.PkgPath = "example.com/foo/internal/testutil"
+		assert.Equal(Test, r.Classify(f).Kind)
+
+		f = file(t, "util.go", "package testutil")
+		f.PkgPath = "example.com/foo/testutil"
+		assert.Equal(Production, r.Classify(f).Kind)
+
+		assert.Equal(Production, r.Classify(file(t, "util.go", "package testutil")).Kind)
+	})
+
+	t.Run("files", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{TestSupportFiles: []string{"*_mock.go", "fakes/*.go"}}
+
+		assert.Equal(Classification{Kind: TestSupport, Reason: `matches "*_mock.go"`}, r.Classify(file(t, "/src/foo/foo_mock.go", "package foo")))
+		assert.Equal(Classification{Kind: TestSupport, Reason: `matches "fakes/*.go"`}, r.Classify(file(t, "/src/foo/fakes/foo.go", "package fakes")))
+		assert.Equal(TestSupport, r.Classify(file(t, "/src/foo/foo_mock.go", "// Code generated by MockGen. DO NOT EDIT.\n\npackage foo")).Kind)
+		assert.Equal(Production, r.Classify(file(t, "/src/foo/foo.go", "package foo")).Kind)
+	})
+
+	t.Run("imports", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := Rules{TestSupportImports: true}
+
+		fake := file(t, "fake.go", "package foo\n\nimport \"testing\"")
+		foo := file(t, "foo.go", "package foo")
+		test := file(t, "foo_test.go", "package foo\n\nimport \"testing\"")
+		for _, f := range []*File{fake, foo, test} {
+			f.PkgFiles = []*File{fake, foo, test}
+		}
+		assert.Equal(Classification{Kind: TestSupport, Reason: `package imports "testing" in non-test files`}, r.Classify(foo))
+		assert.Equal(TestSupport, r.Classify(fake).Kind)
+		assert.Equal(Test, r.Classify(test).Kind)
+
+		foo = file(t, "foo.go", "package foo")
+		test = file(t, "foo_test.go", "package foo\n\nimport \"testing\"")
+		for _, f := range []*File{foo, test} {
+			f.PkgFiles = []*File{foo, test}
+		}
+		assert.Equal(Production, r.Classify(foo).Kind)
+
+		// A test file processed by cgo is a test file.
+		foo = file(t, "foo.go", "package foo")
+		test = file(t, "/cache/b1/foo_test.cgo1.go", "// Code generated by cmd/cgo; DO NOT EDIT.\n\n//line /src/foo/foo_test.go:1:1\npackage foo\n\nimport \"testing\"")
+		for _, f := range []*File{foo, test} {
+			f.PkgFiles = []*File{foo, test}
+		}
+		assert.Equal(Production, r.Classify(foo).Kind)
+
+		assert.Equal(Production, (&Rules{}).Classify(fake).Kind)
+	})
+}
+
+func TestRules_Classify_LineDirectives(t *testing.T) {
+	t.Run("mapped test file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "/cache/b1/foo.cgo1.go", "//line /src/foo/foo_test.go:1:1\npackage foo")
+
+		assert.Equal(Production, (&Rules{}).Classify(f).Kind)
+		assert.Equal(Test, (&Rules{LineDirectives: true}).Classify(f).Kind)
+		assert.Equal("/src/foo/foo_test.go", f.Name(true))
+		assert.Equal("/cache/b1/foo.cgo1.go", f.Name(false))
+	})
+
+	t.Run("mapped from non-Go source", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "/src/foo/parser.go", "//line parser.y:2\npackage foo\n\n//line parser.y:10:3\nfunc parse() {}")
+
+		assert.Equal(Production, (&Rules{}).Classify(f).Kind)
+		assert.Equal(Classification{Kind: Generated, Reason: "//line directive to parser.y"}, (&Rules{LineDirectives: true}).Classify(f))
+	})
+
+	t.Run("block directive", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "/src/foo/lexer.go", "package foo\n\nfunc lex() { /*line lexer.rl:42*/ }")
+
+		assert.Equal(Classification{Kind: Generated, Reason: "//line directive to lexer.rl"}, (&Rules{LineDirectives: true}).Classify(f))
+	})
+
+	t.Run("prose comment", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "/src/foo/foo.go", "package foo\n\n//line up the values\nvar x = 1\n\nfunc f() {\n\t//line parser.y:10\n}")
+
+		assert.Equal(Production, (&Rules{LineDirectives: true}).Classify(f).Kind)
+		assert.Equal("/src/foo/foo.go", f.Name(true))
+	})
+
+	t.Run("mapped from Go source", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := file(t, "/cache/b1/foo.cgo1.go", "//line /src/foo/foo.go:1:1\npackage foo")
+
+		assert.Equal(Production, (&Rules{LineDirectives: true}).Classify(f).Kind)
+	})
+}
+
+func TestAnd(t *testing.T) {
+	assert := assert.New(t)
+
+	f := file(t, "foo.go", "package foo")
+
+	assert.Equal(Test, And(always(Test), always(Generated)).Classify(f).Kind)
+	assert.Equal(Production, And(always(Test), always(Production)).Classify(f).Kind)
+	assert.Equal(Production, And(always(Production), always(Production)).Classify(f).Kind)
+	assert.Equal(Production, And().Classify(f).Kind)
+}
+
+func TestOr(t *testing.T) {
+	assert := assert.New(t)
+
+	f := file(t, "foo.go", "package foo")
+
+	assert.Equal(Test, Or(always(Test), always(Generated)).Classify(f).Kind)
+	assert.Equal(Generated, Or(always(Production), always(Generated)).Classify(f).Kind)
+	assert.Equal(Production, Or(always(Production), always(Production)).Classify(f).Kind)
+	assert.Equal(Production, Or().Classify(f).Kind)
+}
+
+func TestNot(t *testing.T) {
+	assert := assert.New(t)
+
+	f := file(t, "foo.go", "package foo")
+
+	assert.Equal(Classification{Kind: Production, Reason: "negated test"}, Not(always(Test)).Classify(f))
+	assert.Equal(Classification{Kind: Excluded, Reason: "negated"}, Not(always(Production)).Classify(f))
+}
+
+func always(k FileKind) Classifier {
+	return ClassifierFunc(func(*File) Classification {
+		if k.IsProduction() {
+			return Classification{Kind: k}
+		}
+		return Classification{Kind: k, Reason: k.String()}
+	})
+}
+
+func file(t *testing.T, name, src string) *File {
+	fset := token.NewFileSet()
+	f := parse(t, fset, name, src)
+	return NewFile(f, fset.File(f.Pos()))
+}
+
+func parse(tb testing.TB, fset *token.FileSet, name, src string) *ast.File {
+	f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return f
+}
@@ -0,0 +1,117 @@
+// Command prodinspect explains how prodinspect classifies files.
+//
+//	$ prodinspect ls ./...
+//	$ prodinspect ls -json ./...
+//
+// ls accepts the same flags as prodinspect.Analyzer to change the classification.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/ichiban/prodinspect"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "ls":
+		if err := ls(os.Stdout, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "prodinspect: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: prodinspect ls [flags] [packages]\n")
+}
+
+type entry struct {
+	Package string               `json:"package"`
+	File    string               `json:"file"`
+	Kind    prodinspect.FileKind `json:"kind"`
+	Reason  string               `json:"reason,omitempty"`
+}
+
+func ls(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ContinueOnError)
+	j := fs.Bool("json", false, "emit JSON output")
+	var r prodinspect.Rules
+	r.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax,
+		Tests: true,
+	}, fs.Args()...)
+	if err != nil {
+		return err
+	}
+	packages.PrintErrors(pkgs)
+
+	wd, _ := os.Getwd()
+	seen := map[string]bool{}
+	entries := []entry{}
+	for _, p := range pkgs {
+		// Skip test main packages synthesized by go test.
+		if strings.HasSuffix(p.ID, ".test") {
+			continue
+		}
+
+		opts, err := prodinspect.OptionsFor(p.Fset, p.Syntax, &r)
+		if err != nil {
+			return err
+		}
+		i := prodinspect.NewFromFiles(p.Fset, p.Syntax, append(opts, prodinspect.WithPackagePath(p.PkgPath))...)
+		for _, f := range p.Syntax {
+			name := p.Fset.File(f.Pos()).Name()
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+				name = rel
+			}
+
+			c := i.Classify(f)
+			entries = append(entries, entry{
+				Package: p.PkgPath,
+				File:    name,
+				Kind:    c.Kind,
+				Reason:  c.Reason,
+			})
+		}
+	}
+
+	if *j {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(entries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.File, e.Kind, e.Reason)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/ichiban/prodinspect"
+)
+
+func TestLs(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var buf bytes.Buffer
+		assert.NoError(ls(&buf, []string{"../../testdata/src/a"}))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(lines, 2)
+		assert.Regexp(`/testdata/src/a/a\.go +production *$`, lines[0])
+		assert.Regexp(`/testdata/src/a/a_test\.go +test +_test\.go suffix$`, lines[1])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var buf bytes.Buffer
+		assert.NoError(ls(&buf, []string{"-json", "-exclude", "a.go", "../../testdata/src/a"}))
+
+		var entries []map[string]string
+		assert.NoError(json.Unmarshal(buf.Bytes(), &entries))
+		assert.Len(entries, 2)
+		assert.Equal("excluded", entries[0]["kind"])
+		assert.Equal(`matches "a.go"`, entries[0]["reason"])
+		assert.Equal("test", entries[1]["kind"])
+	})
+}
+
+func TestLs_Config(t *testing.T) {
+	assert := assert.New(t)
+
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":               "module example.com/m\n\ngo 1.25\n",
+		prodinspect.ConfigFile: `{"exclude": ["a.go"]}`,
+		"a.go":                 "package m",
+		"b.go":                 "package m",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(root)
+
+	var buf bytes.Buffer
+	assert.NoError(ls(&buf, []string{"-json", "./..."}))
+
+	var entries []struct {
+		File string
+		Kind string
+	}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &entries))
+	listed := map[string]string{}
+	for _, e := range entries {
+		listed[e.File] = e.Kind
+	}
+	assert.Equal(map[string]string{"a.go": "excluded", "b.go": "production"}, listed)
+
+	// The analyzer reads the same configuration and agrees.
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+	}, "./...")
+	assert.NoError(err)
+	for _, p := range pkgs {
+		r, err := prodinspect.Analyzer.Run(&analysis.Pass{
+			Fset:  p.Fset,
+			Files: p.Syntax,
+		})
+		assert.NoError(err)
+
+		i := r.(*prodinspect.Inspector)
+		for _, f := range p.Syntax {
+			name := filepath.Base(p.Fset.File(f.Pos()).Name())
+			assert.Equal(listed[name], i.Classify(f).Kind.String(), name)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+// Command prodvet is go vet but for production code only.
+//
+//	$ prodvet ./...
+//	$ go vet -vettool=$(which prodvet) ./...
+//
+// To run your own analyzers as well, write a main package calling prodvet.Main with them.
+package main
+
+import (
+	"github.com/ichiban/prodinspect/prodvet"
+)
+
+func main() {
+	prodvet.Main()
+}
@@ -0,0 +1,223 @@
+package prodinspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ConfigFile is the name of the configuration file at the module root.
+const ConfigFile = ".prodinspect.json"
+
+// config is the content of ConfigFile.
+// Lists are added to the ones configured by flags except build-tags which replaces them.
+// Flags set explicitly take precedence over the other values.
+type config struct {
+	IncludeTests        *bool              `json:"include-tests"`
+	IncludeGenerated    *bool              `json:"include-generated"`
+	Include             []string           `json:"include"`
+	Exclude             []string           `json:"exclude"`
+	BuildTags           []string           `json:"build-tags"`
+	GeneratedMarkers    []string           `json:"generated-markers"`
+	Generators          []string           `json:"generators"`
+	TestSupportPackages []string           `json:"test-support-packages"`
+	TestSupportFiles    []string           `json:"test-support-files"`
+	TestSupportImports  *bool              `json:"test-support-imports"`
+	GitAttributes       *bool              `json:"gitattributes"`
+	LineDirectives      *bool              `json:"line-directives"`
+	RegionBegin         *string            `json:"region-begin"`
+	RegionEnd           *string            `json:"region-end"`
+	Analyzers           map[string]*config `json:"analyzers"`
+
+	markers []*regexp.Regexp
+}
+
+// validate checks the values and compiles the generated markers.
+func (c *config) validate(nested bool) error {
+	for _, ps := range [][]string{c.Include, c.Exclude, c.TestSupportPackages, c.TestSupportFiles} {
+		for _, p := range ps {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+	}
+	for _, m := range c.GeneratedMarkers {
+		e, err := regexp.Compile(m)
+		if err != nil {
+			return fmt.Errorf("invalid generated marker %q: %w", m, err)
+		}
+		c.markers = append(c.markers, e)
+	}
+	for _, n := range c.Generators {
+		if _, ok := generator(n); !ok {
+			return fmt.Errorf("unknown generator: %s", n)
+		}
+	}
+	if nested && c.Analyzers != nil {
+		return fmt.Errorf("analyzers can't be nested")
+	}
+	if nested && (c.RegionBegin != nil || c.RegionEnd != nil) {
+		return fmt.Errorf("region markers can't be overridden for analyzers")
+	}
+	for name, a := range c.Analyzers {
+		if a == nil {
+			return fmt.Errorf("analyzers.%s: null", name)
+		}
+		if err := a.validate(true); err != nil {
+			return fmt.Errorf("analyzers.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// apply returns a copy of r overridden by c except the values of the flags set explicitly.
+func (c *config) apply(r *Rules) *Rules {
+	n := *r
+	set := func(name string, dst, src *bool) {
+		if src != nil && !r.explicit[name] {
+			*dst = *src
+		}
+	}
+	set("include-tests", &n.IncludeTests, c.IncludeTests)
+	set("include-generated", &n.IncludeGenerated, c.IncludeGenerated)
+	set("test-support-imports", &n.TestSupportImports, c.TestSupportImports)
+	set("gitattributes", &n.GitAttributes, c.GitAttributes)
+	set("line-directives", &n.LineDirectives, c.LineDirectives)
+	n.Include = append(r.Include[:len(r.Include):len(r.Include)], c.Include...)
+	n.Exclude = append(r.Exclude[:len(r.Exclude):len(r.Exclude)], c.Exclude...)
+	n.GeneratedMarkers = append(r.GeneratedMarkers[:len(r.GeneratedMarkers):len(r.GeneratedMarkers)], c.markers...)
+	n.Generators = append(r.Generators[:len(r.Generators):len(r.Generators)], c.Generators...)
+	n.TestSupportPackages = append(r.TestSupportPackages[:len(r.TestSupportPackages):len(r.TestSupportPackages)], c.TestSupportPackages...)
+	n.TestSupportFiles = append(r.TestSupportFiles[:len(r.TestSupportFiles):len(r.TestSupportFiles)], c.TestSupportFiles...)
+	if c.BuildTags != nil && !r.explicit["build-tags"] {
+		n.BuildTags = c.BuildTags
+	}
+	return &n
+}
+
+// OptionsFor returns Options to classify files by r further configured by ConfigFile at the module root of files.
+// Analyzer, Load and the prodinspect command share it so that they classify files in the same way.
+func OptionsFor(fset Filer, files []*ast.File, r *Rules) ([]Option, error) {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		if tf := fset.File(f.Pos()); tf != nil {
+			names = append(names, tf.Name())
+		}
+	}
+	c, _, err := loadConfig(names)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return []Option{WithClassifier(r)}, nil
+	}
+
+	rules := c.apply(r)
+	opts := []Option{WithClassifier(rules), c.regionMarkers(r.explicit)}
+	for name, a := range c.Analyzers {
+		opts = append(opts, WithAnalyzerClassifier(name, a.apply(rules)))
+	}
+	return opts, nil
+}
+
+// regionMarkers returns an Option to replace region markers with the ones in c if any unless the flags are set explicitly.
+func (c *config) regionMarkers(explicit map[string]bool) Option {
+	return func(i *Inspector) {
+		if c.RegionBegin != nil && !explicit["region-begin"] {
+			i.regionBegin = *c.RegionBegin
+		}
+		if c.RegionEnd != nil && !explicit["region-end"] {
+			i.regionEnd = *c.RegionEnd
+		}
+	}
+}
+
+// configEntry is a loaded ConfigFile.
+type configEntry struct {
+	modTime time.Time
+	size    int64
+	c       *config
+	err     error
+}
+
+// configs caches configurations by their paths.
+// Entries are reloaded when the files are modified.
+var configs sync.Map // map[string]*configEntry
+
+// loadConfig loads ConfigFile at the module root of the first file in names which belongs to a module.
+// It returns nil without an error if there's no such file.
+func loadConfig(names []string) (*config, string, error) {
+	for _, name := range names {
+		root, ok := moduleRoot(filepath.Dir(name))
+		if !ok {
+			continue
+		}
+		p := filepath.Join(root, ConfigFile)
+		fi, err := os.Stat(p)
+		if err != nil {
+			configs.Delete(p)
+			if os.IsNotExist(err) {
+				return nil, p, nil
+			}
+			return nil, p, err
+		}
+		if v, ok := configs.Load(p); ok {
+			e := v.(*configEntry)
+			if e.modTime.Equal(fi.ModTime()) && e.size == fi.Size() {
+				return e.c, p, e.err
+			}
+		}
+		c, err := readConfig(p)
+		configs.Store(p, &configEntry{
+			modTime: fi.ModTime(),
+			size:    fi.Size(),
+			c:       c,
+			err:     err,
+		})
+		return c, p, err
+	}
+	return nil, "", nil
+}
+
+func readConfig(name string) (*config, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var c config
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := c.validate(false); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &c, nil
+}
+
+// moduleRoot returns the nearest directory with go.mod from dir.
+func moduleRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
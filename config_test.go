@@ -0,0 +1,249 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+func module(t *testing.T, config string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if config != "" {
+		if err := os.WriteFile(filepath.Join(root, ConfigFile), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLoadConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	root := module(t, `{
+	"include-tests": true,
+	"exclude": ["*_mock.go"],
+	"generated-markers": ["^// Autogenerated"],
+	"build-tags": ["integration"],
+	"analyzers": {
+		"nestif": {"include-tests": false, "exclude": ["legacy/*.go"]}
+	}
+}`)
+
+	c, p, err := loadConfig([]string{"/nonexistent/foo.go", filepath.Join(root, "sub", "foo.go")})
+	assert.NoError(err)
+	assert.Equal(filepath.Join(root, ConfigFile), p)
+	assert.Equal([]string{"*_mock.go"}, c.Exclude)
+	assert.Len(c.markers, 1)
+
+	r := c.apply(&Rules{Exclude: []string{"*_fake.go"}})
+	assert.True(r.IncludeTests)
+	assert.Equal([]string{"*_fake.go", "*_mock.go"}, r.Exclude)
+	assert.Equal([]string{"integration"}, r.BuildTags)
+
+	n := c.Analyzers["nestif"].apply(r)
+	assert.False(n.IncludeTests)
+	assert.Equal([]string{"*_fake.go", "*_mock.go", "legacy/*.go"}, n.Exclude)
+	assert.Equal([]string{"*_fake.go", "*_mock.go"}, r.Exclude)
+
+	// The configuration is cached until the file is modified.
+	d, _, err := loadConfig([]string{filepath.Join(root, "foo.go")})
+	assert.NoError(err)
+	assert.Same(c, d)
+
+	assert.NoError(os.WriteFile(filepath.Join(root, ConfigFile), []byte(`{"exclude": ["*_fake.go", "*_mock.go"]}`), 0644))
+	d, _, err = loadConfig([]string{filepath.Join(root, "foo.go")})
+	assert.NoError(err)
+	assert.Equal([]string{"*_fake.go", "*_mock.go"}, d.Exclude)
+
+	assert.NoError(os.Remove(filepath.Join(root, ConfigFile)))
+	d, _, err = loadConfig([]string{filepath.Join(root, "foo.go")})
+	assert.NoError(err)
+	assert.Nil(d)
+
+	c, _, err = loadConfig([]string{filepath.Join(module(t, ""), "foo.go")})
+	assert.NoError(err)
+	assert.Nil(c)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		title  string
+		config string
+		err    string
+	}{
+		{title: "syntax", config: `{"exclude": [}`, err: "invalid character"},
+		{title: "unknown field", config: `{"excludes": []}`, err: `unknown field "excludes"`},
+		{title: "type", config: `{"include-tests": "yes"}`, err: "cannot unmarshal string"},
+		{title: "pattern", config: `{"exclude": ["[a-"]}`, err: `invalid pattern "[a-"`},
+		{title: "marker", config: `{"generated-markers": ["("]}`, err: `invalid generated marker "("`},
+		{title: "generator", config: `{"generators": ["unknown"]}`, err: "unknown generator: unknown"},
+		{title: "analyzer", config: `{"analyzers": {"nestif": {"generators": ["unknown"]}}}`, err: "analyzers.nestif: unknown generator: unknown"},
+		{title: "nested", config: `{"analyzers": {"nestif": {"analyzers": {}}}}`, err: "analyzers.nestif: analyzers can't be nested"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert := assert.New(t)
+
+			root := module(t, tc.config)
+			_, _, err := loadConfig([]string{filepath.Join(root, "foo.go")})
+			if assert.Error(err) {
+				assert.Contains(err.Error(), filepath.Join(root, ConfigFile))
+				assert.Contains(err.Error(), tc.err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Fixed(t *testing.T) {
+	assert := assert.New(t)
+
+	root := module(t, `{"generators": ["unknown"]}`)
+	name := filepath.Join(root, "foo.go")
+
+	_, _, err := loadConfig([]string{name})
+	assert.Error(err)
+
+	assert.NoError(os.WriteFile(filepath.Join(root, ConfigFile), []byte(`{"generators": ["thrift"]}`), 0644))
+	c, _, err := loadConfig([]string{name})
+	assert.NoError(err)
+	assert.Equal([]string{"thrift"}, c.Generators)
+}
+
+func TestNewAnalyzer_Config(t *testing.T) {
+	assert := assert.New(t)
+
+	root := module(t, `{
+	"exclude": ["*_mock.go"],
+	"analyzers": {
+		"nestif": {"include-tests": true}
+	}
+}`)
+
+	fs := token.NewFileSet()
+	prod := parse(t, fs, filepath.Join(root, "foo.go"), "package foo")
+	mock := parse(t, fs, filepath.Join(root, "foo_mock.go"), "package foo")
+	test := parse(t, fs, filepath.Join(root, "foo_test.go"), "package foo")
+
+	r, err := NewAnalyzer().Run(&analysis.Pass{
+		Fset:  fs,
+		Files: []*ast.File{prod, mock, test},
+	})
+	assert.NoError(err)
+
+	i := r.(*Inspector)
+	assert.Equal(Production, i.Classify(prod).Kind)
+	assert.Equal(Excluded, i.Classify(mock).Kind)
+	assert.Equal(Test, i.Classify(test).Kind)
+	assert.Equal(Test, i.For("cyclomatic").Classify(test).Kind)
+
+	n := i.For("nestif")
+	assert.Equal(Production, n.Classify(test).Kind)
+	assert.Equal(Excluded, n.Classify(mock).Kind)
+
+	var files []ast.Node
+	n.Preorder([]ast.Node{
+		(*ast.File)(nil),
+	}, func(f ast.Node) {
+		files = append(files, f)
+	})
+	assert.Equal([]ast.Node{prod, test}, files)
+}
+
+func TestNewAnalyzer_ConfigRegionsAndInclude(t *testing.T) {
+	assert := assert.New(t)
+
+	root := module(t, `{
+	"include": ["keep_test.go"],
+	"region-begin": "// BEGIN",
+	"region-end": "// END"
+}`)
+
+	fs := token.NewFileSet()
+	prod := parse(t, fs, filepath.Join(root, "foo.go"), "package foo\n\n// BEGIN\nfunc Gen() {}\n// END\n\nfunc Foo() {}")
+	keep := parse(t, fs, filepath.Join(root, "keep_test.go"), "package foo\n\nfunc Keep() {}")
+
+	r, err := NewAnalyzer().Run(&analysis.Pass{
+		Fset:  fs,
+		Files: []*ast.File{prod, keep},
+	})
+	assert.NoError(err)
+
+	var names []string
+	for fn := range Of[*ast.FuncDecl](r.(*Inspector)) {
+		names = append(names, fn.Name.Name)
+	}
+	assert.Equal([]string{"Foo", "Keep"}, names)
+}
+
+func TestNewAnalyzer_ConfigAndFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	root := module(t, `{
+	"include-tests": true,
+	"build-tags": ["integration"],
+	"region-begin": "// BEGIN"
+}`)
+
+	fs := token.NewFileSet()
+	prod := parse(t, fs, filepath.Join(root, "foo.go"), "package foo\n\n// START\nfunc Gen() {}\n// END GENERATED\n\nfunc Foo() {}")
+	integ := parse(t, fs, filepath.Join(root, "bar.go"), "//go:build integration\n\npackage foo\n\nfunc Bar() {}")
+	test := parse(t, fs, filepath.Join(root, "foo_test.go"), "package foo\n\nfunc TestFoo() {}")
+
+	funcs := func(a *analysis.Analyzer) []string {
+		r, err := a.Run(&analysis.Pass{
+			Fset:  fs,
+			Files: []*ast.File{prod, integ, test},
+		})
+		assert.NoError(err)
+
+		var names []string
+		for fn := range Of[*ast.FuncDecl](r.(*Inspector)) {
+			names = append(names, fn.Name.Name)
+		}
+		return names
+	}
+
+	assert.Equal([]string{"Gen", "Foo", "TestFoo"}, funcs(NewAnalyzer()))
+
+	// Flags set explicitly take precedence over the configuration.
+	a := NewAnalyzer()
+	assert.NoError(a.Flags.Set("include-tests", "false"))
+	assert.NoError(a.Flags.Set("build-tags", "ignore"))
+	assert.NoError(a.Flags.Set("region-begin", "// START"))
+	assert.Equal([]string{"Foo", "Bar"}, funcs(a))
+}
+
+func TestLoadConfig_NestedRegions(t *testing.T) {
+	assert := assert.New(t)
+
+	root := module(t, `{"analyzers": {"nestif": {"region-begin": "// BEGIN"}}}`)
+	_, _, err := loadConfig([]string{filepath.Join(root, "foo.go")})
+	assert.EqualError(err, filepath.Join(root, ConfigFile)+": analyzers.nestif: region markers can't be overridden for analyzers")
+}
+
+func TestNewAnalyzer_InvalidConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	root := module(t, `{"generated-markers": ["("]}`)
+
+	fs := token.NewFileSet()
+	f := parse(t, fs, filepath.Join(root, "foo.go"), "package foo")
+
+	_, err := NewAnalyzer().Run(&analysis.Pass{
+		Fset:  fs,
+		Files: []*ast.File{f},
+	})
+	assert.Error(err)
+}
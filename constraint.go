@@ -0,0 +1,109 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"sort"
+)
+
+// DefaultBuildTags are the build tags which make files non-production by default.
+var DefaultBuildTags = []string{"ignore", "tools"}
+
+// maxConstraintTags is the maximum number of tags in a build constraint to be examined.
+const maxConstraintTags = 16
+
+// buildConstraint returns the build constraint of f if any.
+// A //go:build line takes precedence over // +build lines as go/build does.
+func buildConstraint(f *ast.File) (constraint.Expr, string, bool) {
+	var (
+		plus  constraint.Expr
+		lines string
+	)
+	for _, c := range f.Comments {
+		if c.Pos() > f.Package {
+			break
+		}
+		for _, l := range c.List {
+			switch {
+			case constraint.IsGoBuild(l.Text):
+				x, err := constraint.Parse(l.Text)
+				if err != nil {
+					continue
+				}
+				return x, l.Text, true
+			case constraint.IsPlusBuild(l.Text):
+				x, err := constraint.Parse(l.Text)
+				if err != nil {
+					continue
+				}
+				if plus == nil {
+					plus, lines = x, l.Text
+				} else {
+					plus, lines = &constraint.AndExpr{X: plus, Y: x}, lines+"\n"+l.Text
+				}
+			}
+		}
+	}
+	return plus, lines, plus != nil
+}
+
+// requires returns the tags x requires, i.e. x can't be satisfied unless one of them is set.
+func requires(x constraint.Expr, tags []string) ([]string, bool) {
+	set := map[string]bool{}
+	for _, t := range tags {
+		set[t] = true
+	}
+
+	var (
+		req    []string
+		others []string
+		seen   = map[string]bool{}
+	)
+	walkTags(x, func(tag string) {
+		if seen[tag] {
+			return
+		}
+		seen[tag] = true
+		if set[tag] {
+			req = append(req, tag)
+		} else {
+			others = append(others, tag)
+		}
+	})
+	if len(req) == 0 || len(others) > maxConstraintTags {
+		return nil, false
+	}
+
+	// Try all the assignments of the other tags with the given tags unset.
+	for n := 0; n < 1<<len(others); n++ {
+		ok := x.Eval(func(tag string) bool {
+			for i, o := range others {
+				if o == tag {
+					return n&(1<<i) != 0
+				}
+			}
+			return false
+		})
+		if ok {
+			return nil, false
+		}
+	}
+	sort.Strings(req)
+	return req, true
+}
+
+// walkTags calls f for each tag in x.
+func walkTags(x constraint.Expr, f func(tag string)) {
+	switch x := x.(type) {
+	case *constraint.TagExpr:
+		f(x.Tag)
+	case *constraint.NotExpr:
+		walkTags(x.X, f)
+	case *constraint.AndExpr:
+		walkTags(x.X, f)
+		walkTags(x.Y, f)
+	case *constraint.OrExpr:
+		walkTags(x.X, f)
+		walkTags(x.Y, f)
+	}
+}
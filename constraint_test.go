@@ -0,0 +1,72 @@
+package prodinspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_Classify_BuildTags(t *testing.T) {
+	for _, tc := range []struct {
+		title  string
+		tags   []string
+		src    string
+		result Classification
+	}{
+		{
+			title:  "ignore",
+			src:    "//go:build ignore\n\npackage main",
+			result: Classification{Kind: Excluded, Reason: `"//go:build ignore" requires ignore`},
+		},
+		{
+			title:  "tools",
+			src:    "//go:build tools\n// +build tools\n\npackage tools",
+			result: Classification{Kind: Excluded, Reason: `"//go:build tools" requires tools`},
+		},
+		{
+			title:  "plus build",
+			src:    "// +build linux\n// +build tools\n\npackage tools",
+			result: Classification{Kind: Excluded, Reason: `"// +build linux\n// +build tools" requires tools`},
+		},
+		{
+			title:  "negated",
+			src:    "//go:build !tools\n\npackage foo",
+			result: Classification{Kind: Production},
+		},
+		{
+			title:  "alternative",
+			src:    "//go:build tools || linux\n\npackage foo",
+			result: Classification{Kind: Production},
+		},
+		{
+			title:  "conjunction",
+			src:    "//go:build linux && (integration || e2e)\n\npackage foo",
+			tags:   []string{"integration", "e2e"},
+			result: Classification{Kind: Excluded, Reason: `"//go:build linux && (integration || e2e)" requires e2e,integration`},
+		},
+		{
+			title:  "custom tags",
+			src:    "//go:build ignore\n\npackage main",
+			tags:   []string{"integration"},
+			result: Classification{Kind: Production},
+		},
+		{
+			title:  "no tags",
+			src:    "//go:build ignore\n\npackage main",
+			tags:   []string{},
+			result: Classification{Kind: Production},
+		},
+		{
+			title:  "after package clause",
+			src:    "package main\n\n//go:build ignore",
+			result: Classification{Kind: Production},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r := Rules{BuildTags: tc.tags}
+			assert.Equal(tc.result, r.Classify(file(t, "foo.go", tc.src)))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"iter"
+)
+
+// FileInfo describes the file a node is in.
+type FileInfo struct {
+	AST            *ast.File
+	Token          *token.File
+	Classification Classification
+
+	// PkgPath is the import path of the package if known.
+	PkgPath string
+}
+
+// PreorderContext calls f for each node of the types in production files in depth-first order with the file it's in.
+// If types is empty, all the nodes are visited.
+func (i *Inspector) PreorderContext(types []ast.Node, f func(n ast.Node, info FileInfo)) {
+	var info FileInfo
+	i.WithStack(types, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return false
+		}
+		info = i.infoOf(info, n, stack)
+		f(n, info)
+		return true
+	})
+}
+
+// WithStackContext calls f for each node of the types in production files in the same manner as WithStack with the file it's in.
+// If types is empty, all the nodes are visited.
+func (i *Inspector) WithStackContext(types []ast.Node, f func(n ast.Node, push bool, stack []ast.Node, info FileInfo) (proceed bool)) {
+	var info FileInfo
+	i.WithStack(types, func(n ast.Node, push bool, stack []ast.Node) bool {
+		info = i.infoOf(info, n, stack)
+		return f(n, push, stack, info)
+	})
+}
+
+// AllContext returns an iterator over the nodes of the types in production files in depth-first order with the files they're in.
+// If types is empty, all the nodes are visited.
+func (i *Inspector) AllContext(types ...ast.Node) iter.Seq2[ast.Node, FileInfo] {
+	return func(yield func(ast.Node, FileInfo) bool) {
+		var (
+			info FileInfo
+			stop bool
+		)
+		i.WithStack(types, func(n ast.Node, push bool, stack []ast.Node) bool {
+			if stop || !push {
+				return false
+			}
+			info = i.infoOf(info, n, stack)
+			if !yield(n, info) {
+				stop = true
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// infoOf returns the FileInfo of the file n is in. It returns prev if n is in the same file.
+// The file is the root of stack unless the base inspector roots it otherwise, in which case it's looked up by the position of n.
+func (i *Inspector) infoOf(prev FileInfo, n ast.Node, stack []ast.Node) FileInfo {
+	var file *ast.File
+	if len(stack) > 0 {
+		file, _ = stack[0].(*ast.File)
+	}
+	if file == nil {
+		file, _ = i.known(n.Pos())
+	}
+	switch {
+	case file == nil:
+		return FileInfo{Token: i.fset.File(n.Pos()), PkgPath: i.pkgPath}
+	case file == prev.AST:
+		return prev
+	default:
+		return i.fileInfo(file)
+	}
+}
+
+func (i *Inspector) fileInfo(f *ast.File) FileInfo {
+	return FileInfo{
+		AST:            f,
+		Token:          i.fset.File(f.Pos()),
+		Classification: i.Classify(f),
+		PkgPath:        i.pkgPath,
+	}
+}
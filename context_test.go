@@ -0,0 +1,120 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+func TestInspector_PreorderContext(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+
+	for _, i := range []*Inspector{
+		NewFromFiles(fs, files, WithPackagePath("example.com/foo")),
+		New(inspector.New(files), fs, WithPackagePath("example.com/foo")),
+	} {
+		type call struct {
+			name string
+			file string
+			info FileInfo
+		}
+		var calls []call
+		i.PreorderContext([]ast.Node{
+			(*ast.FuncDecl)(nil),
+		}, func(n ast.Node, info FileInfo) {
+			calls = append(calls, call{name: n.(*ast.FuncDecl).Name.Name, file: info.Token.Name(), info: info})
+		})
+
+		if assert.Len(calls, 2) {
+			assert.Equal("Foo", calls[0].name)
+			assert.Equal("foo.go", calls[0].file)
+			assert.Equal(files[0], calls[0].info.AST)
+			assert.Equal(Classification{Kind: Production}, calls[0].info.Classification)
+			assert.Equal("example.com/foo", calls[0].info.PkgPath)
+			assert.Equal("Baz", calls[1].name)
+			assert.Equal("baz.go", calls[1].file)
+			assert.Equal(files[3], calls[1].info.AST)
+		}
+
+		var kinds []FileKind
+		i.Tests().PreorderContext(nil, func(n ast.Node, info FileInfo) {
+			if _, ok := n.(*ast.File); ok {
+				kinds = append(kinds, info.Classification.Kind)
+			}
+		})
+		assert.Equal([]FileKind{Test}, kinds)
+	}
+}
+
+func TestInspector_WithStackContext(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+	i := NewFromFiles(fs, files)
+
+	var names []string
+	i.WithStackContext([]ast.Node{
+		(*ast.FuncDecl)(nil),
+	}, func(n ast.Node, push bool, stack []ast.Node, info FileInfo) bool {
+		if push {
+			assert.Equal(stack[0], info.AST)
+			names = append(names, info.Token.Name()+":"+n.(*ast.FuncDecl).Name.Name)
+		}
+		return true
+	})
+	assert.Equal([]string{"foo.go:Foo", "baz.go:Baz"}, names)
+}
+
+func TestInspector_AllContext(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+	i := NewFromFiles(fs, files)
+
+	var names []string
+	for n, info := range i.AllContext((*ast.FuncDecl)(nil)) {
+		names = append(names, info.Token.Name()+":"+n.(*ast.FuncDecl).Name.Name)
+		break
+	}
+	assert.Equal([]string{"foo.go:Foo"}, names)
+}
+
+// rootless is a WithStacker whose stacks aren't rooted at files.
+type rootless struct {
+	*inspector.Inspector
+}
+
+func (r rootless) WithStack(types []ast.Node, f func(n ast.Node, push bool, stack []ast.Node) bool) {
+	r.Inspector.WithStack(types, func(n ast.Node, push bool, stack []ast.Node) bool {
+		return f(n, push, append([]ast.Node{&ast.BadStmt{}}, stack...))
+	})
+}
+
+func TestInspector_PreorderContext_Rootless(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+	i := New(rootless{inspector.New(files)}, fs)
+
+	var infos []FileInfo
+	i.PreorderContext([]ast.Node{
+		(*ast.FuncDecl)(nil),
+	}, func(n ast.Node, info FileInfo) {
+		infos = append(infos, info)
+	})
+	if assert.Len(infos, 2) {
+		assert.Equal(files[0], infos[0].AST)
+		assert.Equal(files[3], infos[1].AST)
+		assert.Equal("baz.go", infos[1].Token.Name())
+	}
+}
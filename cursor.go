@@ -0,0 +1,156 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"iter"
+	"reflect"
+
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Rooter is an inspector which provides cursors such as *inspector.Inspector.
+type Rooter interface {
+	Root() inspector.Cursor
+}
+
+// Cursor is a position in the syntax trees which never moves into non-production files, generated regions nor opted-out declarations.
+type Cursor struct {
+	c inspector.Cursor
+	i *Inspector
+}
+
+// Root returns a cursor for the virtual root node whose children are the files.
+// If the base inspector isn't a Rooter, an inspector of the files is built on the first call.
+func (i *Inspector) Root() Cursor {
+	if r, ok := i.base.(Rooter); ok {
+		return Cursor{c: r.Root(), i: i}
+	}
+	i.rooter.once.Do(func() {
+		i.rooter.in = inspector.New(i.files)
+	})
+	return Cursor{c: i.rooter.in.Root(), i: i}
+}
+
+// Valid reports whether the cursor is valid.
+// The zero Cursor is invalid and visits nothing.
+func (c Cursor) Valid() bool {
+	return c.i != nil && c.c.Valid()
+}
+
+// Node returns the node at the cursor. It returns nil for the root.
+func (c Cursor) Node() ast.Node {
+	if !c.Valid() {
+		return nil
+	}
+	return c.c.Node()
+}
+
+// Unwrap returns the underlying cursor which can move into any files.
+func (c Cursor) Unwrap() inspector.Cursor {
+	return c.c
+}
+
+// Parent returns the parent of the cursor.
+func (c Cursor) Parent() Cursor {
+	if !c.Valid() {
+		return Cursor{}
+	}
+	return Cursor{c: c.c.Parent(), i: c.i}
+}
+
+// Children returns an iterator over the children of the cursor.
+func (c Cursor) Children() iter.Seq[Cursor] {
+	return func(yield func(Cursor) bool) {
+		if !c.Valid() {
+			return
+		}
+		for d := range c.c.Children() {
+			if !c.i.enters(d.Node()) {
+				continue
+			}
+			if !yield(Cursor{c: d, i: c.i}) {
+				return
+			}
+		}
+	}
+}
+
+// Preorder returns an iterator over the nodes of the types in the subtree of the cursor in depth-first order.
+// If types is empty, all the nodes are visited.
+func (c Cursor) Preorder(types ...ast.Node) iter.Seq[Cursor] {
+	return func(yield func(Cursor) bool) {
+		if !c.Valid() {
+			return
+		}
+		if c.i.direct() {
+			for d := range c.c.Preorder(types...) {
+				if !yield(Cursor{c: d, i: c.i}) {
+					return
+				}
+			}
+			return
+		}
+
+		ts := typesOf(types)
+		var stop bool
+		c.c.Inspect(nil, func(d inspector.Cursor) bool {
+			n := d.Node()
+			if stop || !c.i.enters(n) {
+				return false
+			}
+			if (len(ts) == 0 || ts[reflect.TypeOf(n)]) && !yield(Cursor{c: d, i: c.i}) {
+				stop = true
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// Enclosing returns an iterator over the nodes of the types enclosing the cursor, starting with the cursor itself.
+// If types is empty, all the nodes are visited.
+func (c Cursor) Enclosing(types ...ast.Node) iter.Seq[Cursor] {
+	return func(yield func(Cursor) bool) {
+		if !c.Valid() {
+			return
+		}
+		for d := range c.c.Enclosing(types...) {
+			if !yield(Cursor{c: d, i: c.i}) {
+				return
+			}
+		}
+	}
+}
+
+// FindNode returns the cursor for n if it's in the subtree of the cursor and not excluded.
+func (c Cursor) FindNode(n ast.Node) (Cursor, bool) {
+	if !c.Valid() {
+		return Cursor{}, false
+	}
+	d, ok := c.c.FindNode(n)
+	if !ok {
+		return Cursor{}, false
+	}
+	for e := range d.Enclosing() {
+		if !c.i.enters(e.Node()) {
+			return Cursor{}, false
+		}
+	}
+	return Cursor{c: d, i: c.i}, true
+}
+
+// enters reports whether traversals enter n.
+func (i *Inspector) enters(n ast.Node) bool {
+	if f, ok := n.(*ast.File); ok {
+		return !i.ignored(f)
+	}
+	return !i.excluded(n)
+}
+
+func typesOf(types []ast.Node) map[reflect.Type]bool {
+	ts := make(map[reflect.Type]bool, len(types))
+	for _, t := range types {
+		ts[reflect.TypeOf(t)] = true
+	}
+	return ts
+}
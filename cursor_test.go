@@ -0,0 +1,153 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"iter"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+func cursorFiles(t *testing.T, fs *token.FileSet) []*ast.File {
+	return []*ast.File{
+		parse(t, fs, "foo.go", "package foo\n\nfunc Foo() {\n\tif true {\n\t}\n}\n\n//prodinspect:ignore\nfunc reset() {}\n\n// BEGIN GENERATED\nfunc Gen() {}\n// END GENERATED\n"),
+		parse(t, fs, "foo_test.go", "package foo\n\nfunc TestFoo() {}"),
+		parse(t, fs, "bar.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo\n\nfunc Bar() {}"),
+		parse(t, fs, "baz.go", "package foo\n\nfunc Baz() {}"),
+	}
+}
+
+func funcNames(cs iter.Seq[Cursor]) []string {
+	var names []string
+	for c := range cs {
+		names = append(names, c.Node().(*ast.FuncDecl).Name.Name)
+	}
+	return names
+}
+
+func TestCursor_Preorder(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+
+	for _, i := range []*Inspector{
+		NewFromFiles(fs, files),
+		New(inspector.New(files), fs),
+	} {
+		assert.Equal([]string{"Foo", "Baz"}, funcNames(i.Root().Preorder((*ast.FuncDecl)(nil))))
+		assert.Equal([]string{"TestFoo"}, funcNames(i.Tests().Root().Preorder((*ast.FuncDecl)(nil))))
+		assert.Equal([]string{"Bar"}, funcNames(i.Generated().Root().Preorder((*ast.FuncDecl)(nil))))
+
+		var n int
+		for range i.Root().Preorder() {
+			n++
+			break
+		}
+		assert.Equal(1, n)
+	}
+
+	// Without regions nor ignores, the traversal is delegated to the filtered inspector.
+	i := NewFromFiles(fs, files[1:])
+	assert.Equal([]string{"Baz"}, funcNames(i.Root().Preorder((*ast.FuncDecl)(nil))))
+}
+
+func TestCursor_FindNode(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+	i := New(inspector.New(files), fs)
+
+	ifStmt := files[0].Decls[0].(*ast.FuncDecl).Body.List[0]
+	c, ok := i.Root().FindNode(ifStmt)
+	assert.True(ok)
+	assert.Equal(ifStmt, c.Node())
+
+	for _, n := range []ast.Node{
+		files[0].Decls[1],
+		files[0].Decls[2],
+		files[1].Decls[0],
+		files[2].Decls[0],
+	} {
+		_, ok := i.Root().FindNode(n)
+		assert.False(ok)
+	}
+}
+
+func TestCursor_Enclosing(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+	i := NewFromFiles(fs, files)
+
+	c, ok := i.Root().FindNode(files[0].Decls[0].(*ast.FuncDecl).Body.List[0])
+	assert.True(ok)
+
+	var nodes []ast.Node
+	for e := range c.Enclosing((*ast.FuncDecl)(nil), (*ast.File)(nil)) {
+		nodes = append(nodes, e.Node())
+	}
+	assert.Equal([]ast.Node{files[0].Decls[0], files[0]}, nodes)
+	assert.Equal(files[0].Decls[0].(*ast.FuncDecl).Body, c.Parent().Node())
+}
+
+func TestCursor_Children(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+	i := New(inspector.New(files), fs)
+
+	var nodes []ast.Node
+	for c := range i.Root().Children() {
+		nodes = append(nodes, c.Node())
+	}
+	assert.Equal([]ast.Node{files[0], files[3]}, nodes)
+
+	f, ok := i.Root().FindNode(files[0])
+	assert.True(ok)
+
+	nodes = nil
+	for c := range f.Children() {
+		nodes = append(nodes, c.Node())
+	}
+	assert.Equal([]ast.Node{files[0].Name, files[0].Decls[0]}, nodes)
+}
+
+func TestInspector_Root(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+
+	// The base inspector without Root() falls back to an inspector of the files.
+	i := New(struct{ WithStacker }{inspector.New(files)}, fs)
+	assert.True(i.Root().Valid())
+	assert.Equal([]string{"Foo", "Baz"}, funcNames(i.Root().Preorder((*ast.FuncDecl)(nil))))
+	assert.Equal(i.Root().Unwrap(), i.Root().Unwrap())
+
+	i = NewFromFiles(fs, files)
+	assert.True(i.Root().Valid())
+	assert.Nil(i.Root().Node())
+	assert.Equal(i.filtered.Root(), i.Root().Unwrap())
+}
+
+func TestCursor_Zero(t *testing.T) {
+	assert := assert.New(t)
+
+	var c Cursor
+	assert.False(c.Valid())
+	assert.Nil(c.Node())
+	assert.False(c.Parent().Valid())
+	assert.Empty(funcNames(c.Preorder()))
+	assert.Empty(funcNames(c.Children()))
+	assert.Empty(funcNames(c.Enclosing()))
+
+	_, ok := c.FindNode(&ast.File{})
+	assert.False(ok)
+}
@@ -0,0 +1,90 @@
+package prodinspect
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Generator is a signature of a code generator which doesn't follow `// Code generated * DO NOT EDIT.`.
+type Generator struct {
+	// Name identifies the generator in Rules.Generators.
+	Name string
+
+	// Markers are regular expressions of comment lines the generator writes.
+	Markers []*regexp.Regexp
+
+	// Files are glob patterns of file names the generator writes in the same manner as Rules.Exclude.
+	Files []string
+}
+
+// Generators is the catalog of known generators which can be enabled by Rules.Generators.
+var Generators = []*Generator{
+	{
+		Name:    "protoc-gen-go",
+		Markers: []*regexp.Regexp{regexp.MustCompile(`^// Code generated by protoc-gen-(go|gogo)\.$`)},
+	},
+	{
+		Name:  "kubernetes",
+		Files: []string{"zz_generated.*.go"},
+	},
+	{
+		Name:    "bindata",
+		Markers: []*regexp.Regexp{regexp.MustCompile(`^// Code generated by go-bindata\.`)},
+		Files:   []string{"bindata.go"},
+	},
+	{
+		Name:    "autogenerated",
+		Markers: []*regexp.Regexp{regexp.MustCompile(`(?i)^// This file (was|is) auto-?generated\b`)},
+	},
+	{
+		Name:    "thrift",
+		Markers: []*regexp.Regexp{regexp.MustCompile(`^// Autogenerated by Thrift Compiler\b`)},
+	},
+	{
+		Name:    "swagger",
+		Markers: []*regexp.Regexp{regexp.MustCompile(`^// This file was generated by the swagger tool\.`)},
+	},
+	{
+		Name:    "genny",
+		Markers: []*regexp.Regexp{regexp.MustCompile(`^// This file was automatically generated by genny\.`)},
+	},
+}
+
+// generator returns the generator in the catalog by name.
+func generator(name string) (*Generator, bool) {
+	for _, g := range Generators {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// classify classifies a file which the generator writes as Generated.
+// name is the file name to match against Files.
+func (g *Generator) classify(f *File, name string, mode GeneratedMode) Classification {
+	if p, ok := match(name, g.Files); ok {
+		return Classification{Kind: Generated, Reason: fmt.Sprintf("%s generator: %s file name", g.Name, p)}
+	}
+	if len(g.Markers) > 0 {
+		if l, _ := scan(f.AST, mode, g.Markers...); l != "" {
+			return Classification{Kind: Generated, Reason: fmt.Sprintf("%s generator: %q comment", g.Name, l)}
+		}
+	}
+	return Classification{Kind: Production}
+}
+
+// classifyGenerators classifies a file with the enabled generators in the catalog.
+// Unknown names are ignored.
+func classifyGenerators(f *File, name string, mode GeneratedMode, names []string) Classification {
+	for _, n := range names {
+		g, ok := generator(n)
+		if !ok {
+			continue
+		}
+		if c := g.classify(f, name, mode); !c.Kind.IsProduction() {
+			return c
+		}
+	}
+	return Classification{Kind: Production}
+}
@@ -0,0 +1,94 @@
+package prodinspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerators(t *testing.T) {
+	for _, tc := range []struct {
+		generator string
+		name      string
+		src       string
+		reason    string
+	}{
+		{
+			generator: "protoc-gen-go",
+			name:      "foo.pb.go",
+			src:       "// Code generated by protoc-gen-go.\n// source: foo.proto\n// DO NOT EDIT!\n\npackage foo",
+			reason:    `protoc-gen-go generator: "// Code generated by protoc-gen-go." comment`,
+		},
+		{
+			generator: "kubernetes",
+			name:      "/src/apis/v1/zz_generated.deepcopy.go",
+			src:       "package v1",
+			reason:    "kubernetes generator: zz_generated.*.go file name",
+		},
+		{
+			generator: "bindata",
+			name:      "/src/assets/bindata.go",
+			src:       "package assets",
+			reason:    "bindata generator: bindata.go file name",
+		},
+		{
+			generator: "bindata",
+			name:      "assets.go",
+			src:       "// Code generated by go-bindata.\n// sources:\n// assets/index.html\n\npackage assets",
+			reason:    `bindata generator: "// Code generated by go-bindata." comment`,
+		},
+		{
+			generator: "autogenerated",
+			name:      "foo.go",
+			src:       "// This file was autogenerated by a tool. Do not edit it manually!\n\npackage foo",
+			reason:    `autogenerated generator: "// This file was autogenerated by a tool. Do not edit it manually!" comment`,
+		},
+		{
+			generator: "thrift",
+			name:      "foo.go",
+			src:       "// Autogenerated by Thrift Compiler (0.9.3)\n// DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING\n\npackage foo",
+			reason:    `thrift generator: "// Autogenerated by Thrift Compiler (0.9.3)" comment`,
+		},
+		{
+			generator: "swagger",
+			name:      "foo.go",
+			src:       "// This file was generated by the swagger tool.\n// Editing this file might prove futile when you re-run the swagger generate command\n\npackage foo",
+			reason:    `swagger generator: "// This file was generated by the swagger tool." comment`,
+		},
+		{
+			generator: "genny",
+			name:      "foo.go",
+			src:       "// This file was automatically generated by genny.\n// Any changes will be lost if this file is regenerated.\n\npackage foo",
+			reason:    `genny generator: "// This file was automatically generated by genny." comment`,
+		},
+	} {
+		t.Run(tc.generator, func(t *testing.T) {
+			assert := assert.New(t)
+
+			f := file(t, tc.name, tc.src)
+
+			assert.Equal(Production, (&Rules{}).Classify(f).Kind)
+			assert.Equal(Classification{Kind: Generated, Reason: tc.reason}, (&Rules{Generators: []string{tc.generator}}).Classify(f))
+			assert.Equal(Production, (&Rules{Generators: []string{tc.generator}, IncludeGenerated: true}).Classify(f).Kind)
+		})
+	}
+}
+
+func TestGenerators_Individually(t *testing.T) {
+	assert := assert.New(t)
+
+	thrift := file(t, "foo.go", "// Autogenerated by Thrift Compiler (0.9.3)\n\npackage foo")
+	deepcopy := file(t, "zz_generated.deepcopy.go", "package foo")
+
+	r := Rules{Generators: []string{"thrift", "unknown"}}
+	assert.Equal(Generated, r.Classify(thrift).Kind)
+	assert.Equal(Production, r.Classify(deepcopy).Kind)
+}
+
+func TestGenerators_Standard(t *testing.T) {
+	assert := assert.New(t)
+
+	r := Rules{Generators: []string{"bindata"}}
+	assert.Equal(Classification{Kind: Generated, Reason: `"// Code generated by go-bindata. DO NOT EDIT." comment`}, r.Classify(file(t, "bindata.go", "// Code generated by go-bindata. DO NOT EDIT.\n\npackage foo")))
+	assert.Equal(Classification{Kind: GeneratedTest, Reason: "_test.go suffix, thrift generator: \"// Autogenerated by Thrift Compiler\" comment"}, (&Rules{Generators: []string{"thrift"}}).Classify(file(t, "foo_test.go", "// Autogenerated by Thrift Compiler\n\npackage foo")))
+}
@@ -0,0 +1,37 @@
+package prodinspect
+
+import (
+	"go/ast"
+)
+
+// Preorder calls f for each node of type T in production files in depth-first order.
+// If T is an interface type such as ast.Expr, f is called for the nodes which implement it.
+func Preorder[T ast.Node](i *Inspector, f func(n T)) {
+	i.Preorder(filterOf[T](), func(n ast.Node) {
+		if t, ok := n.(T); ok {
+			f(t)
+		}
+	})
+}
+
+// WithStackOf calls f for each node of type T in production files in the same manner as WithStack.
+// If T is an interface type such as ast.Expr, f is called for the nodes which implement it.
+func WithStackOf[T ast.Node](i *Inspector, f func(n T, push bool, stack []ast.Node) (proceed bool)) {
+	i.WithStack(filterOf[T](), func(n ast.Node, push bool, stack []ast.Node) bool {
+		t, ok := n.(T)
+		if !ok {
+			return true
+		}
+		return f(t, push, stack)
+	})
+}
+
+// filterOf returns the type filter for T.
+// Interface types need all the nodes to be visited.
+func filterOf[T ast.Node]() []ast.Node {
+	var zero T
+	if any(zero) == nil {
+		return nil
+	}
+	return []ast.Node{zero}
+}
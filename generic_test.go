@@ -0,0 +1,222 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// filterFiles returns the files used in TestFilter_* with an optional header comment.
+func filterFiles(header string) []*ast.File {
+	f := &ast.File{
+		Name: &ast.Ident{},
+		Decls: []ast.Decl{
+			&ast.FuncDecl{
+				Name: &ast.Ident{},
+				Type: &ast.FuncType{
+					Params: &ast.FieldList{},
+				},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.ExprStmt{
+							X: &ast.CallExpr{
+								Fun: &ast.Ident{},
+							},
+						},
+					},
+				},
+			},
+			&ast.FuncDecl{
+				Name: &ast.Ident{},
+				Type: &ast.FuncType{
+					Params: &ast.FieldList{},
+				},
+				Body: &ast.BlockStmt{},
+			},
+		},
+	}
+	if header != "" {
+		f.Comments = []*ast.CommentGroup{
+			{
+				List: []*ast.Comment{
+					{
+						Text: header,
+					},
+				},
+			},
+		}
+	}
+	return []*ast.File{f}
+}
+
+func filterInspector(name string, files []*ast.File) *Inspector {
+	fs := token.NewFileSet()
+	fs.AddFile(name, 1, 1)
+
+	return &Inspector{
+		base: inspector.New(files),
+		fset: &MockFiler{
+			file: fs.File(1),
+		},
+	}
+}
+
+func TestPreorder(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		files := filterFiles("")
+		i := filterInspector("foo.go", files)
+
+		var result []*ast.File
+		Preorder(i, func(f *ast.File) {
+			result = append(result, f)
+		})
+
+		assert.Equal(files, result)
+	})
+
+	t.Run("with test file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		i := filterInspector("foo_test.go", filterFiles(""))
+
+		Preorder(i, func(*ast.FuncDecl) {
+			assert.Fail("shouldn't be called")
+		})
+	})
+
+	t.Run("with generated file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		i := filterInspector("foo.go", filterFiles("// Code generated by a generator; DO NOT EDIT."))
+
+		Preorder(i, func(*ast.FuncDecl) {
+			assert.Fail("shouldn't be called")
+		})
+	})
+
+	t.Run("without file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		files := filterFiles("")
+		i := filterInspector("foo.go", files)
+
+		var result []*ast.FuncDecl
+		Preorder(i, func(d *ast.FuncDecl) {
+			result = append(result, d)
+		})
+
+		assert.Equal([]*ast.FuncDecl{
+			files[0].Decls[0].(*ast.FuncDecl),
+			files[0].Decls[1].(*ast.FuncDecl),
+		}, result)
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		assert := assert.New(t)
+
+		files := filterFiles("")
+		i := filterInspector("foo.go", files)
+
+		var result []ast.Stmt
+		Preorder(i, func(s ast.Stmt) {
+			result = append(result, s)
+		})
+
+		assert.Equal([]ast.Stmt{
+			files[0].Decls[0].(*ast.FuncDecl).Body,
+			files[0].Decls[0].(*ast.FuncDecl).Body.List[0],
+			files[0].Decls[1].(*ast.FuncDecl).Body,
+		}, result)
+	})
+}
+
+func TestWithStackOf(t *testing.T) {
+	type event struct {
+		push  bool
+		node  ast.Node
+		stack []ast.Node
+	}
+
+	t.Run("file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		files := filterFiles("")
+		i := filterInspector("foo.go", files)
+
+		var events []event
+		WithStackOf(i, func(f *ast.File, push bool, stack []ast.Node) bool {
+			events = append(events, event{push: push, node: f, stack: append(stack[:0:0], stack...)})
+			return true
+		})
+
+		assert.Equal([]event{
+			{push: true, node: files[0], stack: []ast.Node{files[0]}},
+			{push: false, node: files[0], stack: []ast.Node{files[0]}},
+		}, events)
+	})
+
+	t.Run("with test file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		i := filterInspector("foo_test.go", filterFiles(""))
+
+		WithStackOf(i, func(*ast.FuncDecl, bool, []ast.Node) bool {
+			assert.Fail("shouldn't be called")
+			return true
+		})
+	})
+
+	t.Run("with generated file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		i := filterInspector("foo.go", filterFiles("// Code generated by a generator; DO NOT EDIT."))
+
+		WithStackOf(i, func(*ast.FuncDecl, bool, []ast.Node) bool {
+			assert.Fail("shouldn't be called")
+			return true
+		})
+	})
+
+	t.Run("without file", func(t *testing.T) {
+		assert := assert.New(t)
+
+		files := filterFiles("")
+		i := filterInspector("foo.go", files)
+
+		var events []event
+		WithStackOf(i, func(d *ast.FuncDecl, push bool, stack []ast.Node) bool {
+			events = append(events, event{push: push, node: d, stack: append(stack[:0:0], stack...)})
+			return true
+		})
+
+		assert.Equal([]event{
+			{push: true, node: files[0].Decls[0], stack: []ast.Node{files[0], files[0].Decls[0]}},
+			{push: false, node: files[0].Decls[0], stack: []ast.Node{files[0], files[0].Decls[0]}},
+			{push: true, node: files[0].Decls[1], stack: []ast.Node{files[0], files[0].Decls[1]}},
+			{push: false, node: files[0].Decls[1], stack: []ast.Node{files[0], files[0].Decls[1]}},
+		}, events)
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		assert := assert.New(t)
+
+		files := filterFiles("")
+		i := filterInspector("foo.go", files)
+
+		var result []ast.Decl
+		WithStackOf(i, func(d ast.Decl, push bool, _ []ast.Node) bool {
+			if push {
+				result = append(result, d)
+			}
+			return false
+		})
+
+		assert.Equal(files[0].Decls, result)
+	})
+}
@@ -0,0 +1,193 @@
+package prodinspect
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const linguistGenerated = "linguist-generated"
+
+// gitattributes is a parsed .gitattributes file.
+type gitattributes struct {
+	path    string
+	modTime time.Time
+	size    int64
+	rules   []attrRule
+}
+
+// attrRule is a line of .gitattributes which sets or unsets linguist-generated.
+type attrRule struct {
+	pattern   *regexp.Regexp
+	generated bool
+}
+
+// gitattributesCache caches .gitattributes files by their directories.
+// Entries are reloaded when the files are modified.
+var gitattributesCache sync.Map // map[string]*gitattributes
+
+// linguistGeneratedBy returns the .gitattributes file which marks name as linguist-generated.
+// .gitattributes files are looked up from the directory of name up to the root of the repository.
+func linguistGeneratedBy(name string) (string, bool) {
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return "", false
+	}
+
+	var gs []*gitattributes
+	for dir := filepath.Dir(name); ; {
+		if g := loadGitattributes(dir); g != nil {
+			gs = append(gs, g)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// Deeper files take precedence over shallower ones and later lines over earlier ones.
+	var (
+		by        string
+		generated bool
+	)
+	for n := len(gs) - 1; n >= 0; n-- {
+		g := gs[n]
+		rel, err := filepath.Rel(filepath.Dir(g.path), name)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, r := range g.rules {
+			if r.pattern.MatchString(rel) {
+				by, generated = g.path, r.generated
+			}
+		}
+	}
+	if !generated {
+		return "", false
+	}
+	return by, true
+}
+
+func loadGitattributes(dir string) *gitattributes {
+	path := filepath.Join(dir, ".gitattributes")
+	fi, err := os.Stat(path)
+	if err != nil {
+		gitattributesCache.Delete(dir)
+		return nil
+	}
+	if v, ok := gitattributesCache.Load(dir); ok {
+		g := v.(*gitattributes)
+		if g.modTime.Equal(fi.ModTime()) && g.size == fi.Size() {
+			return g
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	g := gitattributes{
+		path:    path,
+		modTime: fi.ModTime(),
+		size:    fi.Size(),
+		rules:   parseGitattributes(f),
+	}
+	gitattributesCache.Store(dir, &g)
+	return &g
+}
+
+func parseGitattributes(r io.Reader) []attrRule {
+	var rules []attrRule
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p string
+		if q, err := strconv.QuotedPrefix(line); err == nil && line[0] == '"' {
+			p, _ = strconv.Unquote(q)
+			line = line[len(q):]
+		} else {
+			i := strings.IndexAny(line, " \t")
+			if i < 0 {
+				continue
+			}
+			p, line = line[:i], line[i:]
+		}
+		for _, a := range strings.Fields(line) {
+			var generated bool
+			switch a {
+			case linguistGenerated, linguistGenerated + "=true":
+				generated = true
+			case "-" + linguistGenerated, "!" + linguistGenerated, linguistGenerated + "=false":
+				generated = false
+			default:
+				continue
+			}
+			e, err := attrPattern(p)
+			if err != nil {
+				continue
+			}
+			rules = append(rules, attrRule{pattern: e, generated: generated})
+		}
+	}
+	return rules
+}
+
+// attrPattern converts a .gitattributes pattern to a regular expression which matches slash-separated relative paths.
+// A pattern without a slash matches the base name in any directory.
+func attrPattern(p string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(strings.TrimSuffix(p, "/"), "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	p = strings.TrimPrefix(p, "/")
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "/**") && i+3 == len(p):
+			b.WriteString("/.*")
+			i += 2
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			j := strings.IndexByte(p[i+1:], ']')
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated character class: %s", p)
+			}
+			class := p[i+1 : i+1+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += j + 1
+		case c == '\\' && i+1 < len(p):
+			i++
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,100 @@
+package prodinspect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttrPattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		matches []string
+		misses  []string
+	}{
+		{pattern: "*.pb.go", matches: []string{"foo.pb.go", "a/b/foo.pb.go"}, misses: []string{"foo.go"}},
+		{pattern: "bindata.go", matches: []string{"bindata.go", "assets/bindata.go"}, misses: []string{"assets/bindata.go.orig"}},
+		{pattern: "/gen/*.go", matches: []string{"gen/foo.go"}, misses: []string{"a/gen/foo.go", "gen/a/foo.go"}},
+		{pattern: "gen/**", matches: []string{"gen/foo.go", "gen/a/foo.go"}, misses: []string{"a/gen/foo.go"}},
+		{pattern: "**/mocks/*.go", matches: []string{"mocks/foo.go", "a/b/mocks/foo.go"}, misses: []string{"mocks/a/foo.go"}},
+		{pattern: "api/**/zz_*.go", matches: []string{"api/zz_foo.go", "api/v1/zz_foo.go"}, misses: []string{"zz_foo.go"}},
+		{pattern: "foo_[!t]*.go", matches: []string{"foo_bar.go"}, misses: []string{"foo_test.go"}},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			e, err := attrPattern(tc.pattern)
+			assert.NoError(err)
+			for _, m := range tc.matches {
+				assert.True(e.MatchString(m), m)
+			}
+			for _, m := range tc.misses {
+				assert.False(e.MatchString(m), m)
+			}
+		})
+	}
+}
+
+func TestParseGitattributes(t *testing.T) {
+	assert := assert.New(t)
+
+	rules := parseGitattributes(strings.NewReader(`# comment
+*.go text eol=lf
+*.pb.go linguist-generated=true
+gen/** linguist-generated -diff
+gen/keep.go -linguist-generated
+"quoted name.go" linguist-generated
+broken[ linguist-generated
+`))
+
+	var generated []bool
+	for _, r := range rules {
+		generated = append(generated, r.generated)
+	}
+	assert.Equal([]bool{true, true, false, true}, generated)
+	assert.True(rules[3].pattern.MatchString("quoted name.go"))
+}
+
+func TestRules_Classify_GitAttributes(t *testing.T) {
+	assert := assert.New(t)
+
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		".git/HEAD":            "ref: refs/heads/main\n",
+		".gitattributes":       "*.pb.go linguist-generated\ngen/** linguist-generated=true\n",
+		"gen/.gitattributes":   "keep.go -linguist-generated\n",
+		"api/.gitattributes":   "*_gen.go linguist-generated\n",
+		"foo.go":               "package foo",
+		"foo.pb.go":            "package foo",
+		"gen/foo.go":           "package gen",
+		"gen/keep.go":          "package gen",
+		"api/types_gen.go":     "package api",
+		"api/nested/foo.pb.go": "package nested",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(os.WriteFile(path, []byte(content), 0644))
+	}
+
+	r := Rules{GitAttributes: true}
+	classify := func(name string) Classification {
+		return r.Classify(file(t, filepath.Join(root, filepath.FromSlash(name)), "package foo"))
+	}
+
+	assert.Equal(Classification{Kind: Production}, classify("foo.go"))
+	assert.Equal(Classification{Kind: Generated, Reason: "linguist-generated in " + filepath.Join(root, ".gitattributes")}, classify("foo.pb.go"))
+	assert.Equal(Generated, classify("gen/foo.go").Kind)
+	assert.Equal(Production, classify("gen/keep.go").Kind)
+	assert.Equal(Classification{Kind: Generated, Reason: "linguist-generated in " + filepath.Join(root, "api", ".gitattributes")}, classify("api/types_gen.go"))
+	assert.Equal(Generated, classify("api/nested/foo.pb.go").Kind)
+	assert.Equal(Production, classify("foo_gen.go").Kind)
+
+	assert.Equal(Production, (&Rules{}).Classify(file(t, filepath.Join(root, "foo.pb.go"), "package foo")).Kind)
+
+	// Modified .gitattributes files are reloaded.
+	assert.NoError(os.WriteFile(filepath.Join(root, ".gitattributes"), []byte("*.go linguist-generated\n# longer than before\n"), 0644))
+	assert.Equal(Generated, classify("foo.go").Kind)
+}
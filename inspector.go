@@ -4,22 +4,301 @@ import (
 	"go/ast"
 	"go/token"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/ast/inspector"
 )
 
 type Inspector struct {
-	base WithStacker
-	fset Filer
+	base       WithStacker
+	fset       Filer
+	classifier Classifier
+
+	// classes caches classifications of files.
+	classes map[*ast.File]Classification
+
+	// files are the files in the order of traversal.
+	files []*ast.File
+
+	// tokens maps token files to the files.
+	tokens map[*token.File]*ast.File
+
+	// pkgPath is the import path of the package of the files if known.
+	pkgPath string
+
+	// pkgFiles are the files passed to the classifier.
+	pkgFiles []*File
+
+	// selection is the kinds of files traversed.
+	selection selection
+
+	// filtered is an inspector only with the selected files.
+	// If it's not nil and there're no regions nor ignores in the selected files, traversals are delegated to it without filtering.
+	filtered *inspector.Inspector
+
+	// pruned reports whether the selected files have regions or ignores.
+	pruned bool
+
+	// regionBegin and regionEnd are prefixes of comments surrounding generated regions.
+	regionBegin, regionEnd string
+
+	// regions are generated regions in the files sorted by positions.
+	regions []span
+
+	// optouts are declarations with //prodinspect:ignore directive sorted by positions.
+	optouts []optout
+
+	// analyzer is the name of the analyzer which uses the inspector.
+	analyzer string
+
+	// ignores are declarations opted out for the analyzer sorted by positions.
+	ignores []span
+
+	// overrides are classifiers for specific analyzers.
+	overrides map[string]Classifier
+
+	tests, generated view
+
+	// rooter is an inspector of the files for cursors if base isn't a Rooter.
+	rooter struct {
+		once sync.Once
+		in   *inspector.Inspector
+	}
 }
 
-func New(base WithStacker, fset Filer) *Inspector {
+type view struct {
+	once sync.Once
+	i    *Inspector
+}
+
+// selection is the properties of files traversed. The zero selection selects production files.
+type selection struct {
+	tests, generated bool
+}
+
+// selects reports whether files of the kind are traversed.
+func (s selection) selects(k FileKind) bool {
+	switch {
+	case s.tests && s.generated:
+		return k == GeneratedTest
+	case s.tests:
+		return k == Test || k == ExternalTest
+	case s.generated:
+		return k == Generated || k == GeneratedTest
+	default:
+		return k.IsProduction()
+	}
+}
+
+func New(base WithStacker, fset Filer, opts ...Option) *Inspector {
+	i := Inspector{
+		base:        base,
+		fset:        fset,
+		classifier:  DefaultClassifier,
+		regionBegin: DefaultRegionBegin,
+		regionEnd:   DefaultRegionEnd,
+	}
+	for _, o := range opts {
+		o(&i)
+	}
+
+	var files []*ast.File
+	base.WithStack([]ast.Node{(*ast.File)(nil)}, func(n ast.Node, push bool, _ []ast.Node) bool {
+		if push {
+			files = append(files, n.(*ast.File))
+		}
+		return false
+	})
+	i.classify(files)
+
+	return &i
+}
+
+// NewFromFiles returns an Inspector which classifies files beforehand and traverses production files only.
+// It works without the analysis framework, e.g. with files parsed by go/parser.
+func NewFromFiles(fset Filer, files []*ast.File, opts ...Option) *Inspector {
+	i := Inspector{
+		fset:        fset,
+		classifier:  DefaultClassifier,
+		regionBegin: DefaultRegionBegin,
+		regionEnd:   DefaultRegionEnd,
+	}
+	for _, o := range opts {
+		o(&i)
+	}
+
+	i.classify(files)
+	i.filter()
+
+	return &i
+}
+
+// classify caches classifications of files.
+func (i *Inspector) classify(files []*ast.File) {
+	i.files = files
+	i.classes = make(map[*ast.File]Classification, len(files))
+	i.tokens = make(map[*token.File]*ast.File, len(files))
+	i.pkgFiles = make([]*File, len(files))
+	for n, f := range files {
+		i.pkgFiles[n] = i.file(f)
+	}
+	for n, f := range files {
+		i.classes[f] = i.classifyFile(i.pkgFiles[n])
+		i.tokens[i.fset.File(f.Pos())] = f
+		i.regions = append(i.regions, regions(f, i.regionBegin, i.regionEnd)...)
+		i.optouts = append(i.optouts, optouts(f)...)
+	}
+	sort.Slice(i.regions, func(a, b int) bool {
+		return i.regions[a].pos < i.regions[b].pos
+	})
+	sort.Slice(i.optouts, func(a, b int) bool {
+		return i.optouts[a].pos < i.optouts[b].pos
+	})
+	i.ignores = ignores(i.optouts, i.analyzer)
+}
+
+// filter builds an inspector only with the selected files.
+func (i *Inspector) filter() {
+	var files []*ast.File
+	for _, f := range i.files {
+		if !i.ignored(f) {
+			files = append(files, f)
+		}
+	}
+	i.filtered = inspector.New(files)
+	i.base = i.filtered
+	i.pruned = i.prunes(i.regions) || i.prunes(i.ignores)
+}
+
+// prunes reports whether any of spans is in the selected files.
+func (i *Inspector) prunes(spans []span) bool {
+	for _, s := range spans {
+		if f, ok := i.known(s.pos); ok && !i.ignored(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tests returns an Inspector which traverses test files instead of production files.
+// Generated test files are not included unless i is a view of generated files, i.e. i.Generated().Tests() traverses generated test files only.
+func (i *Inspector) Tests() *Inspector {
+	return i.view(&i.tests, func(s *selection) {
+		s.tests = true
+	})
+}
+
+// Generated returns an Inspector which traverses generated files instead of production files.
+// Generated test files are included. i.Tests().Generated() traverses generated test files only.
+func (i *Inspector) Generated() *Inspector {
+	return i.view(&i.generated, func(s *selection) {
+		s.generated = true
+	})
+}
+
+// For returns an Inspector for the analyzer named name.
+// In addition to declarations with //prodinspect:ignore directive, it prunes ones with the directive listing the name.
+// If the analyzer has its own classifier by WithAnalyzerClassifier, files are classified by it.
+func (i *Inspector) For(name string) *Inspector {
+	d := i.derive()
+	d.analyzer = name
+	d.ignores = ignores(d.optouts, name)
+	if c, ok := i.overrides[name]; ok {
+		d.classifier = c
+		d.classes = make(map[*ast.File]Classification, len(d.files))
+		for n, f := range d.files {
+			d.classes[f] = d.classifyFile(d.pkgFiles[n])
+		}
+		if d.filtered != nil {
+			d.filter()
+		}
+	}
+	d.pruned = d.prunes(d.regions) || d.prunes(d.ignores)
+	return d
+}
+
+// view returns the view of i narrowed by narrow.
+// The view keeps the settings of i such as the analyzer given to For.
+func (i *Inspector) view(v *view, narrow func(*selection)) *Inspector {
+	v.once.Do(func() {
+		v.i = i.derive()
+		narrow(&v.i.selection)
+		if i.filtered != nil {
+			v.i.filter()
+		}
+	})
+	return v.i
+}
+
+// derive returns a copy of i without views.
+func (i *Inspector) derive() *Inspector {
 	return &Inspector{
-		base: base,
-		fset: fset,
+		base:        i.base,
+		fset:        i.fset,
+		classifier:  i.classifier,
+		classes:     i.classes,
+		files:       i.files,
+		tokens:      i.tokens,
+		pkgPath:     i.pkgPath,
+		pkgFiles:    i.pkgFiles,
+		selection:   i.selection,
+		filtered:    i.filtered,
+		pruned:      i.pruned,
+		regionBegin: i.regionBegin,
+		regionEnd:   i.regionEnd,
+		regions:     i.regions,
+		optouts:     i.optouts,
+		analyzer:    i.analyzer,
+		ignores:     i.ignores,
+		overrides:   i.overrides,
+	}
+}
+
+// Option configures Inspector.
+type Option func(*Inspector)
+
+// WithClassifier replaces DefaultClassifier with c.
+func WithClassifier(c Classifier) Option {
+	return func(i *Inspector) {
+		i.classifier = c
+	}
+}
+
+// WithRegionMarkers replaces DefaultRegionBegin and DefaultRegionEnd with begin and end.
+// Traversals prune nodes between comments starting with begin and end.
+// If either of them is empty, no regions are pruned.
+func WithRegionMarkers(begin, end string) Option {
+	return func(i *Inspector) {
+		i.regionBegin = begin
+		i.regionEnd = end
+	}
+}
+
+// WithAnalyzerClassifier makes Inspectors returned by For(name) classify files by c.
+func WithAnalyzerClassifier(name string, c Classifier) Option {
+	return func(i *Inspector) {
+		if i.overrides == nil {
+			i.overrides = map[string]Classifier{}
+		}
+		i.overrides[name] = c
+	}
+}
+
+// WithPackagePath tells the classifier the import path of the package of the files.
+func WithPackagePath(path string) Option {
+	return func(i *Inspector) {
+		i.pkgPath = path
 	}
 }
 
 func (i *Inspector) Preorder(types []ast.Node, f func(n ast.Node)) {
+	if i.direct() {
+		i.filtered.Preorder(types, f)
+		return
+	}
+
 	c := containsFile(types)
 
 	if !c {
@@ -32,7 +311,7 @@ func (i *Inspector) Preorder(types []ast.Node, f func(n ast.Node)) {
 		}
 
 		if f, ok := n.(*ast.File); ok {
-			if ignored(f, i.fset) {
+			if i.ignored(f) {
 				return false
 			}
 
@@ -41,6 +320,10 @@ func (i *Inspector) Preorder(types []ast.Node, f func(n ast.Node)) {
 			}
 		}
 
+		if i.excluded(n) {
+			return false
+		}
+
 		f(n)
 
 		return true
@@ -48,6 +331,11 @@ func (i *Inspector) Preorder(types []ast.Node, f func(n ast.Node)) {
 }
 
 func (i *Inspector) Nodes(types []ast.Node, f func(n ast.Node, push bool) (prune bool)) {
+	if i.direct() {
+		i.filtered.Nodes(types, f)
+		return
+	}
+
 	c := containsFile(types)
 
 	if !c {
@@ -56,7 +344,7 @@ func (i *Inspector) Nodes(types []ast.Node, f func(n ast.Node, push bool) (prune
 
 	i.base.WithStack(types, func(n ast.Node, push bool, _ []ast.Node) bool {
 		if f, ok := n.(*ast.File); ok {
-			if ignored(f, i.fset) {
+			if i.ignored(f) {
 				return false
 			}
 
@@ -65,11 +353,20 @@ func (i *Inspector) Nodes(types []ast.Node, f func(n ast.Node, push bool) (prune
 			}
 		}
 
+		if push && i.excluded(n) {
+			return false
+		}
+
 		return f(n, push)
 	})
 }
 
 func (i *Inspector) WithStack(types []ast.Node, f func(n ast.Node, push bool, stack []ast.Node) (prune bool)) {
+	if i.direct() {
+		i.filtered.WithStack(types, f)
+		return
+	}
+
 	c := containsFile(types)
 
 	if !c {
@@ -78,7 +375,7 @@ func (i *Inspector) WithStack(types []ast.Node, f func(n ast.Node, push bool, st
 
 	i.base.WithStack(types, func(n ast.Node, push bool, stack []ast.Node) bool {
 		if f, ok := n.(*ast.File); ok {
-			if ignored(f, i.fset) {
+			if i.ignored(f) {
 				return false
 			}
 
@@ -87,6 +384,10 @@ func (i *Inspector) WithStack(types []ast.Node, f func(n ast.Node, push bool, st
 			}
 		}
 
+		if push && i.excluded(n) {
+			return false
+		}
+
 		return f(n, push, stack)
 	})
 }
@@ -103,22 +404,149 @@ func containsFile(types []ast.Node) bool {
 	return false
 }
 
-func ignored(n *ast.File, fset Filer) bool {
-	f := fset.File(n.Pos())
-	name := f.Name()
+// Classify returns the kind of f with the reason.
+// File-level directives //prodinspect:production and //prodinspect:nonproduction take precedence over the classifier.
+func (i *Inspector) Classify(f *ast.File) Classification {
+	if c, ok := i.classes[f]; ok {
+		return c
+	}
+	return i.classifyFile(i.file(f))
+}
+
+// classifyFile returns the kind of f by the file-level directives or the classifier.
+func (i *Inspector) classifyFile(f *File) Classification {
+	switch _, d := scan(f.AST, GeneratedHeader); d {
+	case productionDirective:
+		return Classification{Kind: Production, Reason: d + " directive"}
+	case nonproductionDirective:
+		return Classification{Kind: Excluded, Reason: d + " directive"}
+	}
 
-	return strings.HasSuffix(name, "_test.go") || generated(n)
+	c := i.classifier
+	if c == nil {
+		c = DefaultClassifier
+	}
+	return c.Classify(f)
+}
+
+// file returns a File for f to be classified.
+// Files processed by cgo are mapped back to their original files so that any classifier sees them.
+func (i *Inspector) file(f *ast.File) *File {
+	file := NewFile(f, i.fset.File(f.Pos()))
+	file.PkgPath = i.pkgPath
+	file.PkgFiles = i.pkgFiles
+	return file
+}
+
+// direct reports whether traversals can be delegated to the filtered inspector.
+func (i *Inspector) direct() bool {
+	return i.filtered != nil && !i.pruned
+}
+
+// excluded reports whether n is in a generated region or an opted-out declaration.
+func (i *Inspector) excluded(n ast.Node) bool {
+	return excluded(i.regions, n) || excluded(i.ignores, n)
+}
+
+// IsProduction reports whether pos is in production code, i.e. code the traversals visit.
+// Positions in generated regions, opted-out declarations or files unknown to i are not in production code.
+func (i *Inspector) IsProduction(pos token.Pos) bool {
+	_, ok := i.ProductionFile(pos)
+	return ok
+}
+
+// ProductionFile returns the file which has pos if pos is in production code.
+func (i *Inspector) ProductionFile(pos token.Pos) (*ast.File, bool) {
+	f, ok := i.known(pos)
+	if !ok || i.ignored(f) || covered(i.regions, pos) || covered(i.ignores, pos) {
+		return nil, false
+	}
+	return f, true
+}
+
+// known returns the file which has pos if i knows it.
+func (i *Inspector) known(pos token.Pos) (*ast.File, bool) {
+	if !pos.IsValid() {
+		return nil, false
+	}
+	f, ok := i.tokens[i.fset.File(pos)]
+	return f, ok
+}
+
+func (i *Inspector) ignored(f *ast.File) bool {
+	return !i.selection.selects(i.Classify(f).Kind)
 }
 
 // https://github.com/golang/go/issues/13560#issuecomment-288457920
 var pattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
 
-func generated(f *ast.File) bool {
+// GeneratedMode is a way to find the generated marker in a file.
+type GeneratedMode int
+
+const (
+	// GeneratedHeader finds the marker in lines of comments before the package clause as go/ast.IsGenerated does.
+	GeneratedHeader GeneratedMode = iota
+
+	// GeneratedAnywhere finds the marker in any line comment in the file as prior versions did.
+	GeneratedAnywhere
+)
+
+// generated returns the line of the generated marker if any.
+// Lines matching one of markers are also considered as the generated marker.
+func generated(f *ast.File, mode GeneratedMode, markers ...*regexp.Regexp) (string, bool) {
+	m, _ := scan(f, mode, append([]*regexp.Regexp{pattern}, markers...)...)
+	return m, m != ""
+}
+
+const (
+	productionDirective    = "//prodinspect:production"
+	nonproductionDirective = "//prodinspect:nonproduction"
+)
+
+// scan finds the line matching one of markers and the file-level directive in the comments of f.
+// The directive has to be before the package clause.
+func scan(f *ast.File, mode GeneratedMode, markers ...*regexp.Regexp) (marker, directive string) {
 	for _, c := range f.Comments {
 		for _, l := range c.List {
-			if pattern.MatchString(l.Text) {
-				return true
+			header := l.Pos() <= f.Package
+			if !header && (mode != GeneratedAnywhere || marker != "") {
+				return marker, directive
 			}
+
+			if header && directive == "" {
+				for _, d := range []string{productionDirective, nonproductionDirective} {
+					if l.Text == d || strings.HasPrefix(l.Text, d+" ") {
+						directive = d
+					}
+				}
+			}
+
+			if marker != "" {
+				continue
+			}
+
+			if mode == GeneratedAnywhere {
+				if isMarker(l.Text, markers) {
+					marker = l.Text
+				}
+				continue
+			}
+
+			for _, line := range strings.Split(l.Text, "\n") {
+				if isMarker(line, markers) {
+					marker = line
+					break
+				}
+			}
+		}
+	}
+	return marker, directive
+}
+
+func isMarker(line string, markers []*regexp.Regexp) bool {
+	for _, m := range markers {
+		if m.MatchString(line) {
+			return true
 		}
 	}
 	return false
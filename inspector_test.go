@@ -1,8 +1,12 @@
 package prodinspect
 
 import (
+	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/token"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"golang.org/x/tools/go/ast/inspector"
@@ -22,6 +26,174 @@ func TestNew(t *testing.T) {
 	assert.Equal(&fset, i.fset)
 }
 
+func TestNewFromFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	prod := parse(t, fs, "foo.go", "package foo\n\nfunc Foo() {}")
+	test := parse(t, fs, "foo_test.go", "package foo\n\nfunc TestFoo() {}")
+	gen := parse(t, fs, "bar.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo\n\nfunc Bar() {}")
+
+	i := NewFromFiles(fs, []*ast.File{prod, test, gen})
+
+	assert.Equal(map[*ast.File]Classification{
+		prod: {Kind: Production},
+		test: {Kind: Test, Reason: "_test.go suffix"},
+		gen:  {Kind: Generated, Reason: `"// Code generated by a generator; DO NOT EDIT." comment`},
+	}, i.classes)
+
+	var result []ast.Node
+	i.Preorder([]ast.Node{
+		(*ast.FuncDecl)(nil),
+	}, func(n ast.Node) {
+		result = append(result, n)
+	})
+	assert.Equal([]ast.Node{prod.Decls[0]}, result)
+
+	result = nil
+	i.Nodes([]ast.Node{
+		(*ast.FuncDecl)(nil),
+	}, func(n ast.Node, push bool) bool {
+		result = append(result, n)
+		return true
+	})
+	assert.Equal([]ast.Node{prod.Decls[0], prod.Decls[0]}, result)
+
+	result = nil
+	i.WithStack([]ast.Node{
+		(*ast.FuncDecl)(nil),
+	}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		result = append(result, n)
+		return true
+	})
+	assert.Equal([]ast.Node{prod.Decls[0], prod.Decls[0]}, result)
+}
+
+func TestInspector_Tests(t *testing.T) {
+	fs := token.NewFileSet()
+	prod := parse(t, fs, "foo.go", "package foo\n\nfunc Foo() {}")
+	test := parse(t, fs, "foo_test.go", "package foo\n\nfunc TestFoo() {}")
+	xtest := parse(t, fs, "bar_test.go", "package foo_test\n\nfunc TestBar() {}")
+	gen := parse(t, fs, "baz_test.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo\n\nfunc TestBaz() {}")
+	files := []*ast.File{prod, test, xtest, gen}
+
+	for name, i := range map[string]*Inspector{
+		"New":      New(inspector.New(files), fs),
+		"filtered": NewFromFiles(fs, files),
+	} {
+		i := i
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var result []ast.Node
+			i.Tests().Preorder([]ast.Node{
+				(*ast.FuncDecl)(nil),
+			}, func(n ast.Node) {
+				result = append(result, n)
+			})
+			assert.Equal([]ast.Node{test.Decls[0], xtest.Decls[0]}, result)
+			assert.Equal(i.Tests(), i.Tests())
+		})
+	}
+}
+
+func TestInspector_Generated(t *testing.T) {
+	fs := token.NewFileSet()
+	prod := parse(t, fs, "foo.go", "package foo\n\nfunc Foo() {}")
+	gen := parse(t, fs, "bar.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo\n\nfunc Bar() {}")
+	gentest := parse(t, fs, "baz_test.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo\n\nfunc TestBaz() {}\n\n//prodinspect:ignore funcs\nfunc TestQux() {}")
+	test := parse(t, fs, "foo_test.go", "package foo\n\nfunc TestFoo() {}")
+	files := []*ast.File{prod, gen, gentest, test}
+
+	for name, i := range map[string]*Inspector{
+		"New":      New(inspector.New(files), fs),
+		"filtered": NewFromFiles(fs, files),
+	} {
+		i := i
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var result []ast.Node
+			i.Generated().WithStack([]ast.Node{
+				(*ast.FuncDecl)(nil),
+			}, func(n ast.Node, push bool, _ []ast.Node) bool {
+				if push {
+					result = append(result, n)
+				}
+				return true
+			})
+			assert.Equal([]ast.Node{gen.Decls[0], gentest.Decls[0], gentest.Decls[1]}, result)
+
+			result = nil
+			i.Generated().Tests().Nodes([]ast.Node{
+				(*ast.FuncDecl)(nil),
+			}, func(n ast.Node, push bool) bool {
+				if push {
+					result = append(result, n)
+				}
+				return true
+			})
+			assert.Equal([]ast.Node{gentest.Decls[0], gentest.Decls[1]}, result)
+
+			result = nil
+			i.Tests().Generated().Preorder([]ast.Node{
+				(*ast.FuncDecl)(nil),
+			}, func(n ast.Node) {
+				result = append(result, n)
+			})
+			assert.Equal([]ast.Node{gentest.Decls[0], gentest.Decls[1]}, result)
+
+			result = nil
+			i.For("funcs").Generated().Tests().Preorder([]ast.Node{
+				(*ast.FuncDecl)(nil),
+			}, func(n ast.Node) {
+				result = append(result, n)
+			})
+			assert.Equal([]ast.Node{gentest.Decls[0]}, result)
+		})
+	}
+}
+
+func TestWithClassifier(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	f := parse(t, fs, "foo_test.go", "package foo")
+
+	i := New(inspector.New([]*ast.File{f}), fs, WithClassifier(Not(TestFiles)))
+
+	var result []ast.Node
+	i.Preorder([]ast.Node{
+		(*ast.File)(nil),
+	}, func(n ast.Node) {
+		result = append(result, n)
+	})
+
+	assert.Equal([]ast.Node{f}, result)
+}
+
+func TestWithPackagePath(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	util := parse(t, fs, "util.go", "package testutil\n\nfunc Util() {}")
+	fake := parse(t, fs, "fake.go", "package testutil\n\nimport \"testing\"\n\nfunc Fake(t *testing.T) {}")
+
+	i := NewFromFiles(fs, []*ast.File{util}, WithPackagePath("example.com/internal/testutil"), WithClassifier(&Rules{TestSupportPackages: []string{"internal/testutil"}}))
+	assert.Equal(TestSupport, i.Classify(util).Kind)
+
+	i = NewFromFiles(fs, []*ast.File{util, fake}, WithClassifier(&Rules{TestSupportImports: true}))
+	assert.Equal(TestSupport, i.Classify(util).Kind)
+
+	var result []ast.Node
+	i.Preorder([]ast.Node{
+		(*ast.FuncDecl)(nil),
+	}, func(n ast.Node) {
+		result = append(result, n)
+	})
+	assert.Empty(result)
+}
+
 func TestFilter_Preorder(t *testing.T) {
 	t.Run("empty types", func(t *testing.T) {
 		assert := assert.New(t)
@@ -1177,6 +1349,40 @@ func TestFilter_WithStack(t *testing.T) {
 	})
 }
 
+func TestGenerated(t *testing.T) {
+	for name, want := range map[string]struct {
+		header, anywhere bool
+	}{
+		"header.go":                        {header: true, anywhere: true},
+		"header_after_build_constraint.go": {header: true, anywhere: true},
+		"header_in_package_doc.go":         {header: true, anywhere: true},
+		"header_line_in_block.go":          {header: true, anywhere: false},
+		"block.go":                         {header: false, anywhere: false},
+		"after_package_clause.go":          {header: false, anywhere: true},
+		"in_function_body.go":              {header: false, anywhere: true},
+		"no_space.go":                      {header: false, anywhere: false},
+		"trailing_text.go":                 {header: false, anywhere: false},
+		"missing_period.go":                {header: false, anywhere: false},
+		"hand_written.go":                  {header: false, anywhere: false},
+	} {
+		name, want := name, want
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			fs := token.NewFileSet()
+			f, err := parser.ParseFile(fs, filepath.Join("testdata", "generated", name), nil, parser.ParseComments)
+			assert.NoError(err)
+
+			_, header := generated(f, GeneratedHeader)
+			assert.Equal(want.header, header)
+			assert.Equal(ast.IsGenerated(f), header)
+
+			_, anywhere := generated(f, GeneratedAnywhere)
+			assert.Equal(want.anywhere, anywhere)
+		})
+	}
+}
+
 type MockFiler struct {
 	file *token.File
 }
@@ -1184,3 +1390,190 @@ type MockFiler struct {
 func (m *MockFiler) File(p token.Pos) (f *token.File) {
 	return m.file
 }
+
+func TestInspector_Classify(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	prod := parse(t, fs, "foo.go", "package foo")
+	test := parse(t, fs, "foo_test.go", "package foo")
+
+	i := New(inspector.New([]*ast.File{prod, test}), fs)
+
+	assert.Equal(Classification{Kind: Production}, i.Classify(prod))
+	assert.Equal(Classification{Kind: Test, Reason: "_test.go suffix"}, i.Classify(test))
+}
+
+func TestInspector_Classify_Directives(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	gen := parse(t, fs, "foo.go", "// Code generated by a generator; DO NOT EDIT.\n\n//prodinspect:production hand-tuned\n\npackage foo")
+	test := parse(t, fs, "foo_test.go", "//prodinspect:production\npackage foo")
+	debug := parse(t, fs, "debug.go", "//go:build debug\n//prodinspect:nonproduction\n\npackage foo")
+	body := parse(t, fs, "bar.go", "package foo\n\n//prodinspect:nonproduction\nvar x int")
+	spaced := parse(t, fs, "baz.go", "// prodinspect:nonproduction\npackage foo")
+
+	i := NewFromFiles(fs, []*ast.File{gen, test, debug, body, spaced})
+
+	assert.Equal(Classification{Kind: Production, Reason: "//prodinspect:production directive"}, i.Classify(gen))
+	assert.Equal(Classification{Kind: Production, Reason: "//prodinspect:production directive"}, i.Classify(test))
+	assert.Equal(Classification{Kind: Excluded, Reason: "//prodinspect:nonproduction directive"}, i.Classify(debug))
+	assert.Equal(Classification{Kind: Production}, i.Classify(body))
+	assert.Equal(Classification{Kind: Production}, i.Classify(spaced))
+
+	var result []ast.Node
+	i.Preorder([]ast.Node{
+		(*ast.File)(nil),
+	}, func(n ast.Node) {
+		result = append(result, n)
+	})
+	assert.Equal([]ast.Node{gen, test, body, spaced}, result)
+}
+
+func BenchmarkPreorder(b *testing.B) {
+	fs := token.NewFileSet()
+	files := synthesize(b, fs, 300, 50)
+	types := []ast.Node{(*ast.CallExpr)(nil)}
+
+	b.Run("inspector", func(b *testing.B) {
+		in := inspector.New(files)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			in.Preorder(types, func(ast.Node) {})
+		}
+	})
+
+	b.Run("New", func(b *testing.B) {
+		i := New(inspector.New(files), fs)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			i.Preorder(types, func(ast.Node) {})
+		}
+	})
+
+	b.Run("filtered", func(b *testing.B) {
+		i := NewFromFiles(fs, files)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			i.Preorder(types, func(ast.Node) {})
+		}
+	})
+
+	// A generated region in a test file doesn't turn off the fast path for production files.
+	region := parse(b, fs, "region_test.go", "package synthetic\n\nfunc TestRegion() {\n\t// BEGIN GENERATED\n\tg(0)\n\t// END GENERATED\n}\n")
+	b.Run("filtered with region in test", func(b *testing.B) {
+		i := NewFromFiles(fs, append(files[:len(files):len(files)], region))
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			i.Preorder(types, func(ast.Node) {})
+		}
+	})
+}
+
+func TestInspector_direct(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	prod := parse(t, fs, "foo.go", "package foo\n\nfunc Foo() {}")
+	region := parse(t, fs, "foo_test.go", "package foo\n\nfunc TestFoo() {\n\t// BEGIN GENERATED\n\tFoo()\n\t// END GENERATED\n}")
+	ignore := parse(t, fs, "bar_test.go", "package foo\n\n//prodinspect:ignore funcs\nfunc TestBar() {}")
+	files := []*ast.File{prod, region, ignore}
+
+	i := NewFromFiles(fs, files)
+	assert.True(i.direct())
+	assert.True(i.For("funcs").direct())
+	assert.False(i.Tests().direct())
+	assert.False(i.For("funcs").Tests().direct())
+	assert.True(i.Generated().direct())
+
+	assert.False(New(inspector.New(files), fs).direct())
+}
+
+func BenchmarkNewFromFiles(b *testing.B) {
+	fs := token.NewFileSet()
+	files := synthesize(b, fs, 300, 50)
+
+	b.Run("inspector", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			inspector.New(files)
+		}
+	})
+
+	b.Run("filtered", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			NewFromFiles(fs, files)
+		}
+	})
+}
+
+// synthesize parses a package of n files with m functions each.
+// A third of them are test files and another third are generated files.
+func synthesize(tb testing.TB, fs *token.FileSet, n, m int) []*ast.File {
+	files := make([]*ast.File, n)
+	for i := range files {
+		var sb strings.Builder
+		name := fmt.Sprintf("file%d.go", i)
+		switch i % 3 {
+		case 1:
+			name = fmt.Sprintf("file%d_test.go", i)
+		case 2:
+			sb.WriteString("// Code generated by synthesize; DO NOT EDIT.\n\n")
+		}
+		sb.WriteString("package synthetic\n")
+		for j := 0; j < m; j++ {
+			fmt.Fprintf(&sb, "\n// F%[1]d_%[2]d does something.\nfunc F%[1]d_%[2]d(a, b int) int {\n\tif a > b {\n\t\treturn g(a - b)\n\t}\n\tfor i := 0; i < b; i++ {\n\t\ta += g(i)\n\t}\n\treturn a\n}\n", i, j)
+		}
+		files[i] = parse(tb, fs, name, sb.String())
+	}
+	return files
+}
+
+func TestInspector_IsProduction(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+	i := NewFromFiles(fs, files)
+
+	foo := files[0].Decls[0].(*ast.FuncDecl)
+	reset := files[0].Decls[1].(*ast.FuncDecl)
+	gen := files[0].Decls[2].(*ast.FuncDecl)
+
+	assert.True(i.IsProduction(foo.Body.Pos()))
+	assert.True(i.IsProduction(files[3].Decls[0].Pos()))
+	assert.False(i.IsProduction(reset.Name.Pos()))
+	assert.False(i.IsProduction(gen.Name.Pos()))
+	assert.False(i.IsProduction(files[1].Decls[0].Pos()))
+	assert.False(i.IsProduction(files[2].Decls[0].Pos()))
+	assert.False(i.IsProduction(token.NoPos))
+
+	other := parse(t, fs, "other.go", "package foo")
+	assert.False(i.IsProduction(other.Pos()))
+
+	assert.True(i.Tests().IsProduction(files[1].Decls[0].Pos()))
+	assert.False(i.Tests().IsProduction(foo.Pos()))
+}
+
+func TestInspector_ProductionFile(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+	i := NewFromFiles(fs, files)
+
+	f, ok := i.ProductionFile(files[0].Decls[0].Pos())
+	assert.True(ok)
+	assert.Equal(files[0], f)
+
+	for _, pos := range []token.Pos{
+		files[0].Decls[1].Pos(),
+		files[1].Decls[0].Pos(),
+		parse(t, fs, "other.go", "package foo").Pos(),
+		token.NoPos,
+	} {
+		f, ok := i.ProductionFile(pos)
+		assert.False(ok)
+		assert.Nil(f)
+	}
+}
@@ -0,0 +1,72 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"iter"
+)
+
+// All returns an iterator over the nodes of the types in production files in depth-first order.
+// If types is empty, all the nodes are visited.
+func (i *Inspector) All(types ...ast.Node) iter.Seq[ast.Node] {
+	return func(yield func(ast.Node) bool) {
+		if i.direct() {
+			for n := range i.filtered.PreorderSeq(types...) {
+				if !yield(n) {
+					return
+				}
+			}
+			return
+		}
+
+		var stop bool
+		i.Nodes(types, func(n ast.Node, push bool) bool {
+			if stop || !push {
+				return false
+			}
+			if !yield(n) {
+				stop = true
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// AllWithStack returns an iterator over the nodes of the types in production files in depth-first order with their stacks.
+// The stack includes the node itself and is only valid until the next iteration.
+// If types is empty, all the nodes are visited.
+func (i *Inspector) AllWithStack(types ...ast.Node) iter.Seq2[ast.Node, []ast.Node] {
+	return func(yield func(ast.Node, []ast.Node) bool) {
+		var stop bool
+		i.WithStack(types, func(n ast.Node, push bool, stack []ast.Node) bool {
+			if stop || !push {
+				return false
+			}
+			if !yield(n, stack) {
+				stop = true
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// Of returns an iterator over the nodes of type T in production files in depth-first order.
+// If T is an interface type such as ast.Expr, the nodes which implement it are visited.
+//
+//	for fn := range prodinspect.Of[*ast.FuncDecl](i) {
+//		// ...
+//	}
+func Of[T ast.Node](i *Inspector) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for n := range i.All(filterOf[T]()...) {
+			t, ok := n.(T)
+			if !ok {
+				continue
+			}
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
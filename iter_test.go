@@ -0,0 +1,103 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+func TestInspector_All(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+
+	for _, tc := range []struct {
+		i     *Inspector
+		funcs []string
+	}{
+		{i: NewFromFiles(fs, files), funcs: []string{"Foo", "Baz"}},
+		{i: NewFromFiles(fs, files[1:]), funcs: []string{"Baz"}},
+		{i: New(inspector.New(files), fs), funcs: []string{"Foo", "Baz"}},
+	} {
+		i := tc.i
+
+		var names []string
+		for n := range i.All((*ast.FuncDecl)(nil)) {
+			names = append(names, n.(*ast.FuncDecl).Name.Name)
+		}
+		assert.Equal(tc.funcs, names)
+
+		names = nil
+		for n := range i.All((*ast.FuncDecl)(nil), (*ast.Ident)(nil)) {
+			if id, ok := n.(*ast.Ident); ok {
+				names = append(names, id.Name)
+				break
+			}
+		}
+		assert.Equal([]string{"foo"}, names)
+	}
+}
+
+func TestInspector_AllWithStack(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+
+	for _, i := range []*Inspector{
+		NewFromFiles(fs, files),
+		New(inspector.New(files), fs),
+	} {
+		var stacks [][]ast.Node
+		for n, stack := range i.AllWithStack((*ast.IfStmt)(nil)) {
+			assert.Equal(n, stack[len(stack)-1])
+			stacks = append(stacks, append([]ast.Node(nil), stack...))
+		}
+		if assert.Len(stacks, 1) {
+			fn := files[0].Decls[0].(*ast.FuncDecl)
+			assert.Equal([]ast.Node{files[0], fn, fn.Body, fn.Body.List[0]}, stacks[0])
+		}
+
+		var n int
+		for range i.AllWithStack() {
+			n++
+			break
+		}
+		assert.Equal(1, n)
+	}
+}
+
+func TestOf(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	files := cursorFiles(t, fs)
+
+	for _, i := range []*Inspector{
+		NewFromFiles(fs, files),
+		New(inspector.New(files), fs),
+	} {
+		var names []string
+		for fn := range Of[*ast.FuncDecl](i) {
+			names = append(names, fn.Name.Name)
+		}
+		assert.Equal([]string{"Foo", "Baz"}, names)
+
+		names = nil
+		for fn := range Of[*ast.FuncDecl](i.Tests()) {
+			names = append(names, fn.Name.Name)
+		}
+		assert.Equal([]string{"TestFoo"}, names)
+
+		names = nil
+		for d := range Of[ast.Decl](i) {
+			names = append(names, d.(*ast.FuncDecl).Name.Name)
+		}
+		assert.Equal([]string{"Foo", "Baz"}, names)
+	}
+}
@@ -0,0 +1,40 @@
+package prodinspect
+
+import (
+	"golang.org/x/tools/go/packages"
+)
+
+// Package is a package loaded by Load with an Inspector for its production files.
+type Package struct {
+	*packages.Package
+	Inspector *Inspector
+}
+
+// Load loads packages with go/packages and returns them with Inspectors.
+// Files are classified by the zero Rules further configured by ConfigFile.
+// cfg.Mode is extended to load syntax trees. If cfg is nil, the default configuration is used.
+func Load(cfg *packages.Config, patterns ...string) ([]*Package, error) {
+	var c packages.Config
+	if cfg != nil {
+		c = *cfg
+	}
+	c.Mode |= packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax
+
+	pkgs, err := packages.Load(&c, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := make([]*Package, len(pkgs))
+	for i, p := range pkgs {
+		opts, err := OptionsFor(p.Fset, p.Syntax, &Rules{})
+		if err != nil {
+			return nil, err
+		}
+		ps[i] = &Package{
+			Package:   p,
+			Inspector: NewFromFiles(p.Fset, p.Syntax, append(opts, WithPackagePath(p.PkgPath))...),
+		}
+	}
+	return ps, nil
+}
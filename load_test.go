@@ -0,0 +1,54 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestLoad(t *testing.T) {
+	assert := assert.New(t)
+
+	ps, err := Load(&packages.Config{Tests: true}, "./testdata/src/a")
+	assert.NoError(err)
+
+	var names []string
+	for _, p := range ps {
+		if p.ID != "github.com/ichiban/prodinspect/testdata/src/a [github.com/ichiban/prodinspect/testdata/src/a.test]" {
+			continue
+		}
+		assert.Len(p.Syntax, 2)
+		p.Inspector.Preorder([]ast.Node{
+			(*ast.FuncDecl)(nil),
+		}, func(n ast.Node) {
+			names = append(names, n.(*ast.FuncDecl).Name.Name)
+		})
+	}
+	assert.Equal([]string{"main", "Foo"}, names)
+}
+
+func TestLoad_Config(t *testing.T) {
+	assert := assert.New(t)
+
+	root := module(t, `{"exclude": ["a.go"]}`)
+	for name, content := range map[string]string{
+		"a.go": "package foo\n\nfunc A() {}",
+		"b.go": "package foo\n\nfunc B() {}",
+	} {
+		assert.NoError(os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+
+	ps, err := Load(&packages.Config{Dir: root}, ".")
+	assert.NoError(err)
+
+	var names []string
+	for fn := range Of[*ast.FuncDecl](ps[0].Inspector) {
+		names = append(names, fn.Name.Name)
+	}
+	assert.Equal([]string{"B"}, names)
+}
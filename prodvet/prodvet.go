@@ -0,0 +1,32 @@
+// Package prodvet runs the vet analyzers over production code only.
+package prodvet
+
+import (
+	"flag"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/multichecker"
+	"golang.org/x/tools/go/analysis/suite/vet"
+
+	"github.com/ichiban/prodinspect"
+)
+
+// Analyzers returns the vet analyzers followed by extra, all of them wrapped by prodinspect.Wrap.
+func Analyzers(extra ...*analysis.Analyzer) []*analysis.Analyzer {
+	as := make([]*analysis.Analyzer, 0, len(vet.Suite)+len(extra))
+	for _, a := range vet.Suite {
+		as = append(as, prodinspect.Wrap(a))
+	}
+	for _, a := range extra {
+		as = append(as, prodinspect.Wrap(a))
+	}
+	return as
+}
+
+// Main runs the vet analyzers and extra over production code only.
+// It accepts the same package patterns as go vet and can be used as go vet -vettool.
+// Flags of prodinspect.Analyzer are available as -prodinspect.<flag>.
+func Main(extra ...*analysis.Analyzer) {
+	prodinspect.RegisterFlags(flag.CommandLine, prodinspect.Analyzer)
+	multichecker.Main(Analyzers(extra...)...)
+}
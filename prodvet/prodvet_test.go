@@ -0,0 +1,24 @@
+package prodvet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/findcall"
+	"golang.org/x/tools/go/analysis/suite/vet"
+)
+
+func TestAnalyzers(t *testing.T) {
+	assert := assert.New(t)
+
+	as := Analyzers(findcall.Analyzer)
+
+	assert.NoError(analysis.Validate(as))
+	assert.Len(as, len(vet.Suite)+1)
+	for i, a := range vet.Suite {
+		assert.Equal(a.Name, as[i].Name)
+	}
+	assert.Equal(findcall.Analyzer.Name, as[len(as)-1].Name)
+}
@@ -0,0 +1,129 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultRegionBegin is the default comment which begins a generated region.
+	DefaultRegionBegin = "// BEGIN GENERATED"
+
+	// DefaultRegionEnd is the default comment which ends a generated region.
+	DefaultRegionEnd = "// END GENERATED"
+)
+
+// span is a range of source code excluded from traversals.
+type span struct {
+	pos, end token.Pos
+}
+
+// contains reports whether n lies inside s.
+func (s span) contains(n ast.Node) bool {
+	return s.pos <= n.Pos() && n.End() <= s.end
+}
+
+// regions returns generated regions in f which are between comments with begin and end prefixes.
+// A region without an end comment lasts until the end of the file.
+func regions(f *ast.File, begin, end string) []span {
+	if begin == "" || end == "" {
+		return nil
+	}
+
+	var (
+		ss   []span
+		open = token.NoPos
+	)
+	for _, c := range f.Comments {
+		for _, l := range c.List {
+			switch {
+			case !open.IsValid() && strings.HasPrefix(l.Text, begin):
+				open = l.Pos()
+			case open.IsValid() && strings.HasPrefix(l.Text, end):
+				ss = append(ss, span{pos: open, end: l.End()})
+				open = token.NoPos
+			}
+		}
+	}
+	if open.IsValid() {
+		ss = append(ss, span{pos: open, end: f.End()})
+	}
+	return ss
+}
+
+// excluded reports whether n lies inside any of spans which are sorted and don't overlap each other.
+func excluded(spans []span, n ast.Node) bool {
+	k := sort.Search(len(spans), func(k int) bool {
+		return spans[k].pos > n.Pos()
+	}) - 1
+	return k >= 0 && spans[k].contains(n)
+}
+
+// covered reports whether pos lies inside any of spans which are sorted and don't overlap each other.
+func covered(spans []span, pos token.Pos) bool {
+	k := sort.Search(len(spans), func(k int) bool {
+		return spans[k].pos > pos
+	}) - 1
+	return k >= 0 && pos < spans[k].end
+}
+
+const ignoreDirective = "//prodinspect:ignore"
+
+// optout is a declaration with //prodinspect:ignore directive.
+type optout struct {
+	span
+
+	// analyzers are names of analyzers listed in the directive. If it's empty, it's for all analyzers.
+	analyzers []string
+}
+
+// optouts returns top-level declarations in f with //prodinspect:ignore directive in their doc comments.
+// The directive can be followed by a comma-separated list of analyzer names.
+func optouts(f *ast.File) []optout {
+	var oo []optout
+	for _, d := range f.Decls {
+		var doc *ast.CommentGroup
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			doc = d.Doc
+		case *ast.GenDecl:
+			doc = d.Doc
+		}
+		if doc == nil {
+			continue
+		}
+		for _, c := range doc.List {
+			if c.Text != ignoreDirective && !strings.HasPrefix(c.Text, ignoreDirective+" ") {
+				continue
+			}
+			oo = append(oo, optout{
+				span: span{pos: d.Pos(), end: d.End()},
+				analyzers: strings.FieldsFunc(strings.TrimPrefix(c.Text, ignoreDirective), func(r rune) bool {
+					return r == ',' || r == ' ' || r == '\t'
+				}),
+			})
+			break
+		}
+	}
+	return oo
+}
+
+// ignores returns spans of oo applicable to the analyzer named name.
+func ignores(oo []optout, name string) []span {
+	var ss []span
+	for _, o := range oo {
+		if len(o.analyzers) == 0 {
+			ss = append(ss, o.span)
+			continue
+		}
+		for _, a := range o.analyzers {
+			if a == name {
+				ss = append(ss, o.span)
+				break
+			}
+		}
+	}
+	return ss
+}
@@ -0,0 +1,202 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegions(t *testing.T) {
+	t.Run("regions", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fs := token.NewFileSet()
+		f := parse(t, fs, "foo.go", `package foo
+
+// BEGIN GENERATED by a tool
+func A() {}
+// END GENERATED
+
+func B() {}
+
+// BEGIN GENERATED
+func C() {}
+`)
+
+		ss := regions(f, DefaultRegionBegin, DefaultRegionEnd)
+		assert.Equal([]span{
+			{pos: f.Comments[0].Pos(), end: f.Comments[1].End()},
+			{pos: f.Comments[2].Pos(), end: f.End()},
+		}, ss)
+
+		assert.True(excluded(ss, f.Decls[0]))
+		assert.False(excluded(ss, f.Decls[1]))
+		assert.True(excluded(ss, f.Decls[2]))
+		assert.False(excluded(ss, f))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fs := token.NewFileSet()
+		f := parse(t, fs, "foo.go", "package foo\n\n// BEGIN GENERATED\nfunc A() {}\n// END GENERATED\n")
+
+		assert.Nil(regions(f, "", DefaultRegionEnd))
+	})
+}
+
+func TestInspector_regions(t *testing.T) {
+	fs := token.NewFileSet()
+	f := parse(t, fs, "foo.go", `package foo
+
+func A() {
+	// BEGIN GENERATED
+	a()
+	// END GENERATED
+	b()
+}
+
+// BEGIN GENERATED
+func C() {}
+// END GENERATED
+`)
+	files := []*ast.File{f}
+	calls := f.Decls[0].(*ast.FuncDecl).Body.List
+
+	for name, i := range map[string]*Inspector{
+		"New":      New(inspector.New(files), fs),
+		"filtered": NewFromFiles(fs, files),
+	} {
+		i := i
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			types := []ast.Node{
+				(*ast.FuncDecl)(nil),
+				(*ast.ExprStmt)(nil),
+			}
+
+			var result []ast.Node
+			i.Preorder(types, func(n ast.Node) {
+				result = append(result, n)
+			})
+			assert.Equal([]ast.Node{f.Decls[0], calls[1]}, result)
+
+			result = nil
+			i.Nodes(types, func(n ast.Node, push bool) bool {
+				result = append(result, n)
+				return true
+			})
+			assert.Equal([]ast.Node{f.Decls[0], calls[1], calls[1], f.Decls[0]}, result)
+
+			result = nil
+			i.WithStack(types, func(n ast.Node, push bool, _ []ast.Node) bool {
+				result = append(result, n)
+				return true
+			})
+			assert.Equal([]ast.Node{f.Decls[0], calls[1], calls[1], f.Decls[0]}, result)
+		})
+	}
+
+	t.Run("without markers", func(t *testing.T) {
+		assert := assert.New(t)
+
+		i := NewFromFiles(fs, files, WithRegionMarkers("", ""))
+
+		var result []ast.Node
+		i.Preorder([]ast.Node{
+			(*ast.FuncDecl)(nil),
+		}, func(n ast.Node) {
+			result = append(result, n)
+		})
+		assert.Equal([]ast.Node{f.Decls[0], f.Decls[1]}, result)
+	})
+}
+
+func TestOptouts(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := token.NewFileSet()
+	f := parse(t, fs, "foo.go", `package foo
+
+// A is ignored by all analyzers.
+//
+//prodinspect:ignore
+func A() {}
+
+//prodinspect:ignore cyclomatic, nestif
+var (
+	b = 1
+	c = 2
+)
+
+// D is not ignored.
+// prodinspect:ignore
+func D() {}
+
+//prodinspect:ignored
+func E() {}
+`)
+
+	oo := optouts(f)
+	assert.Equal([]optout{
+		{span: span{pos: f.Decls[0].Pos(), end: f.Decls[0].End()}, analyzers: []string{}},
+		{span: span{pos: f.Decls[1].Pos(), end: f.Decls[1].End()}, analyzers: []string{"cyclomatic", "nestif"}},
+	}, oo)
+
+	assert.Equal([]span{oo[0].span}, ignores(oo, ""))
+	assert.Equal([]span{oo[0].span, oo[1].span}, ignores(oo, "nestif"))
+	assert.Equal([]span{oo[0].span}, ignores(oo, "printf"))
+}
+
+func TestInspector_For(t *testing.T) {
+	fs := token.NewFileSet()
+	f := parse(t, fs, "foo.go", `package foo
+
+//prodinspect:ignore
+func A() {}
+
+//prodinspect:ignore cyclomatic
+func B() {}
+
+func C() {}
+`)
+	files := []*ast.File{f}
+
+	for name, i := range map[string]*Inspector{
+		"New":      New(inspector.New(files), fs),
+		"filtered": NewFromFiles(fs, files),
+	} {
+		i := i
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			funcs := func(i *Inspector) []ast.Node {
+				var result []ast.Node
+				i.Preorder([]ast.Node{
+					(*ast.FuncDecl)(nil),
+				}, func(n ast.Node) {
+					result = append(result, n)
+				})
+				return result
+			}
+
+			assert.Equal([]ast.Node{f.Decls[1], f.Decls[2]}, funcs(i))
+			assert.Equal([]ast.Node{f.Decls[2]}, funcs(i.For("cyclomatic")))
+			assert.Equal([]ast.Node{f.Decls[1], f.Decls[2]}, funcs(i.For("printf")))
+
+			var result []ast.Node
+			i.For("cyclomatic").WithStack([]ast.Node{
+				(*ast.FuncDecl)(nil),
+			}, func(n ast.Node, push bool, _ []ast.Node) bool {
+				result = append(result, n)
+				return true
+			})
+			assert.Equal([]ast.Node{f.Decls[2], f.Decls[2]}, result)
+		})
+	}
+}
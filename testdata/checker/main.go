@@ -0,0 +1,32 @@
+// Command checker is a singlechecker reporting functions in production code.
+// It requires prodinspect.Analyzer and exposes its flags by prodinspect.RegisterFlags.
+package main
+
+import (
+	"flag"
+	"go/ast"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/ichiban/prodinspect"
+)
+
+var funcs = &analysis.Analyzer{
+	Name:     "funcs",
+	Doc:      "report functions in production code",
+	Requires: []*analysis.Analyzer{prodinspect.Analyzer},
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		i := pass.ResultOf[prodinspect.Analyzer].(*prodinspect.Inspector)
+		i.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+			fn := n.(*ast.FuncDecl)
+			pass.Reportf(fn.Pos(), "%s", fn.Name.Name)
+		})
+		return nil, nil
+	},
+}
+
+func main() {
+	prodinspect.RegisterFlags(flag.CommandLine, prodinspect.Analyzer)
+	singlechecker.Main(funcs)
+}
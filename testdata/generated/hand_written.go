@@ -0,0 +1,2 @@
+// Package generated has nothing generated.
+package generated
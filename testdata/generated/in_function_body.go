@@ -0,0 +1,6 @@
+package generated
+
+func Quote() string {
+	// Code generated by a generator; DO NOT EDIT.
+	return "generated files start with the marker above"
+}
@@ -0,0 +1,8 @@
+package cgo
+
+// #include <stdlib.h>
+import "C"
+
+func Random() int {
+	return int(C.random())
+}
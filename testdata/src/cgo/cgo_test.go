@@ -0,0 +1,9 @@
+package cgo
+
+import (
+	"testing"
+)
+
+func TestRandom(t *testing.T) {
+	Random()
+}
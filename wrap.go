@@ -0,0 +1,24 @@
+package prodinspect
+
+import (
+	"golang.org/x/tools/go/analysis"
+)
+
+// Wrap returns an analyzer which runs a but drops diagnostics in non-production code.
+// Diagnostics without positions or in files other than the Go files, e.g. assembly files, are kept.
+// The returned analyzer shares the name, flags, facts, and requirements with a.
+func Wrap(a *analysis.Analyzer) *analysis.Analyzer {
+	w := *a
+	w.Requires = append(a.Requires[:len(a.Requires):len(a.Requires)], Analyzer)
+	w.Run = func(pass *analysis.Pass) (interface{}, error) {
+		i := pass.ResultOf[Analyzer].(*Inspector).For(a.Name)
+		p := *pass
+		p.Report = func(d analysis.Diagnostic) {
+			if _, ok := i.known(d.Pos); !ok || i.IsProduction(d.Pos) {
+				pass.Report(d)
+			}
+		}
+		return a.Run(&p)
+	}
+	return &w
+}
@@ -0,0 +1,78 @@
+package prodinspect
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+)
+
+type fact struct{}
+
+func (*fact) AFact() {}
+
+func TestWrap(t *testing.T) {
+	assert := assert.New(t)
+
+	var asm token.Pos
+	a := &analysis.Analyzer{
+		Name:      "funcs",
+		Doc:       "report functions",
+		Requires:  []*analysis.Analyzer{inspect.Analyzer},
+		FactTypes: []analysis.Fact{new(fact)},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			for _, f := range pass.Files {
+				for _, d := range f.Decls {
+					pass.Reportf(d.Pos(), "func")
+				}
+			}
+			pass.Report(analysis.Diagnostic{Pos: token.NoPos, Message: "package"})
+			pass.Reportf(asm, "asm")
+			return len(pass.Files), nil
+		},
+		ResultType: reflect.TypeOf(0),
+	}
+	a.Flags.Bool("foo", false, "foo")
+
+	w := Wrap(a)
+
+	assert.Equal(a.Name, w.Name)
+	assert.Equal(a.Doc, w.Doc)
+	assert.Equal(a.FactTypes, w.FactTypes)
+	assert.Equal(a.ResultType, w.ResultType)
+	assert.NotNil(w.Flags.Lookup("foo"))
+	assert.Equal([]*analysis.Analyzer{inspect.Analyzer, Analyzer}, w.Requires)
+	assert.Equal([]*analysis.Analyzer{inspect.Analyzer}, a.Requires)
+
+	fs := token.NewFileSet()
+	prod := parse(t, fs, "foo.go", "package foo\n\nfunc Foo() {}")
+	test := parse(t, fs, "foo_test.go", "package foo\n\nfunc TestFoo() {}")
+	gen := parse(t, fs, "bar.go", "// Code generated by a generator; DO NOT EDIT.\n\npackage foo\n\nfunc Bar() {}")
+	files := []*ast.File{prod, test, gen}
+	asm = fs.AddFile("foo_amd64.s", -1, 10).Pos(0)
+
+	var ds []analysis.Diagnostic
+	r, err := w.Run(&analysis.Pass{
+		Analyzer: w,
+		Fset:     fs,
+		Files:    files,
+		ResultOf: map[*analysis.Analyzer]interface{}{
+			Analyzer: NewFromFiles(fs, files),
+		},
+		Report: func(d analysis.Diagnostic) {
+			ds = append(ds, d)
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(3, r)
+	assert.Equal([]analysis.Diagnostic{
+		{Pos: prod.Decls[0].Pos(), Message: "func"},
+		{Pos: token.NoPos, Message: "package"},
+		{Pos: asm, Message: "asm"},
+	}, ds)
+}